@@ -0,0 +1,443 @@
+package eventlog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/v8platform/brackets"
+)
+
+const (
+	clusterInfobasesFileName = "1CV8Clst.lst"
+	journalDirName           = "1Cv8Log"
+)
+
+// FolderWatcher отслеживает каталоги журналов регистрации, например Manager
+type FolderWatcher interface {
+	Watch(folder string) error
+	Unwatch(folder string) error
+}
+
+var _ FolderWatcher = (*Manager)(nil)
+
+// ClusterInfobase информационная база кластера серверов 1С
+type ClusterInfobase struct {
+	Uuid     string
+	Name     string
+	Registry string // Каталог реестра кластера reg_xxxx
+	Folder   string // Каталог журнала регистрации
+}
+
+type ClusterDiscoveryOptions struct {
+	// Dir каталог srvinfo или каталог реестра кластера reg_xxxx
+	Dir string
+
+	// Interval период повторного поиска информационных баз, по умолчанию 1 минута
+	Interval time.Duration
+
+	Watcher FolderWatcher
+}
+
+// ClusterDiscovery находит журналы регистрации информационных баз кластера серверов 1С.
+//
+// Журналы хранятся в каталогах srvinfo/reg_xxxx/<uuid базы>/1Cv8Log,
+// имена баз берутся из файла реестра кластера 1CV8Clst.lst.
+// Для каждой найденной базы вызывается Watch, для удаленной — Unwatch
+type ClusterDiscovery struct {
+	dir      string
+	interval time.Duration
+	watcher  FolderWatcher
+
+	scanMu sync.Mutex // Поиск выполняется последовательно
+
+	mu        sync.Mutex
+	infobases map[string]ClusterInfobase // По каталогу журнала
+	names     map[string]string          // Имена баз по uuid
+}
+
+func NewClusterDiscovery(opts ClusterDiscoveryOptions) *ClusterDiscovery {
+
+	if opts.Interval <= 0 {
+		opts.Interval = time.Minute
+	}
+
+	return &ClusterDiscovery{
+		dir:       opts.Dir,
+		interval:  opts.Interval,
+		watcher:   opts.Watcher,
+		infobases: make(map[string]ClusterInfobase),
+		names:     make(map[string]string),
+	}
+}
+
+// Run выполняет поиск информационных баз с периодом Interval до отмены контекста
+func (d *ClusterDiscovery) Run(ctx context.Context) error {
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.Scan(); err != nil {
+			log.Printf("cluster discovery: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Scan находит информационные базы, начинает отслеживание журналов новых баз
+// и прекращает отслеживание журналов удаленных баз
+func (d *ClusterDiscovery) Scan() error {
+
+	d.scanMu.Lock()
+	defer d.scanMu.Unlock()
+
+	found, names, err := d.discover()
+	if err != nil {
+		return err
+	}
+
+	var removed, added []ClusterInfobase
+
+	d.mu.Lock()
+
+	// Имена удаленных баз нужны до окончания их выгрузки
+	for uuid, name := range names {
+		d.names[uuid] = name
+	}
+
+	for folder, infobase := range d.infobases {
+		if _, ok := found[folder]; !ok {
+			removed = append(removed, infobase)
+		}
+	}
+
+	for folder, infobase := range found {
+
+		if current, ok := d.infobases[folder]; ok {
+			// База могла быть переименована
+			current.Name = infobase.Name
+			d.infobases[folder] = current
+			continue
+		}
+
+		added = append(added, infobase)
+	}
+
+	d.mu.Unlock()
+
+	// Watch и Unwatch вызываются без блокировки: остановка выгрузки ожидает передачи событий
+	// в хранилища, а хранилище из Tag запрашивает имена баз
+	for _, infobase := range removed {
+
+		if d.watcher != nil {
+			if err := d.watcher.Unwatch(infobase.Folder); err != nil {
+				log.Printf("cluster discovery: unwatch <%s>: %s", infobase.Folder, err)
+			}
+		}
+
+		d.mu.Lock()
+		delete(d.infobases, infobase.Folder)
+		d.mu.Unlock()
+
+		log.Printf("cluster discovery: infobase <%s> removed", infobase.Name)
+	}
+
+	for _, infobase := range added {
+
+		if d.watcher != nil {
+			if err := d.watcher.Watch(infobase.Folder); err != nil {
+				// Попытка повторится при следующем поиске
+				log.Printf("cluster discovery: watch <%s>: %s", infobase.Folder, err)
+				continue
+			}
+		}
+
+		d.mu.Lock()
+		d.infobases[infobase.Folder] = infobase
+		d.mu.Unlock()
+
+		log.Printf("cluster discovery: infobase <%s> added", infobase.Name)
+	}
+
+	d.mu.Lock()
+	d.names = names
+	d.mu.Unlock()
+
+	return nil
+}
+
+// discover возвращает найденные информационные базы по каталогам журналов и имена баз по uuid
+func (d *ClusterDiscovery) discover() (map[string]ClusterInfobase, map[string]string, error) {
+
+	registries := []string{d.dir}
+
+	if _, err := os.Stat(filepath.Join(d.dir, clusterInfobasesFileName)); err != nil {
+		dirs, err := filepath.Glob(filepath.Join(d.dir, "reg_*"))
+		if err != nil {
+			return nil, nil, err
+		}
+		registries = dirs
+	}
+
+	found := make(map[string]ClusterInfobase)
+	names := make(map[string]string)
+
+	for _, registry := range registries {
+
+		registryNames, err := ReadClusterInfobases(filepath.Join(registry, clusterInfobasesFileName))
+		if err != nil && !os.IsNotExist(err) {
+			// Файл мог быть прочитан во время перезаписи кластером, используются прежние имена
+			log.Printf("cluster discovery: read registry <%s>: %s", registry, err)
+			registryNames = d.knownNames()
+		}
+
+		entries, err := ioutil.ReadDir(registry)
+		if err != nil {
+			log.Printf("cluster discovery: read registry <%s>: %s", registry, err)
+			continue
+		}
+
+		for _, entry := range entries {
+
+			uuid := strings.ToLower(entry.Name())
+			if !entry.IsDir() || !isUuid(uuid) {
+				continue
+			}
+
+			folder := filepath.Join(registry, entry.Name(), journalDirName)
+			if info, err := os.Stat(folder); err != nil || !info.IsDir() {
+				continue
+			}
+
+			name, ok := registryNames[uuid]
+			if !ok {
+				name = uuid
+			}
+
+			names[uuid] = name
+			found[folder] = ClusterInfobase{
+				Uuid:     uuid,
+				Name:     name,
+				Registry: registry,
+				Folder:   folder,
+			}
+		}
+	}
+
+	return found, names, nil
+}
+
+// knownNames возвращает имена информационных баз, найденные прошлым сканированием
+func (d *ClusterDiscovery) knownNames() map[string]string {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	names := make(map[string]string, len(d.names))
+	for uuid, name := range d.names {
+		if name != uuid {
+			names[uuid] = name
+		}
+	}
+
+	return names
+}
+
+// Infobases возвращает отслеживаемые информационные базы, упорядоченные по имени
+func (d *ClusterDiscovery) Infobases() []ClusterInfobase {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	infobases := make([]ClusterInfobase, 0, len(d.infobases))
+	for _, infobase := range d.infobases {
+		infobases = append(infobases, infobase)
+	}
+
+	sort.Slice(infobases, func(i, j int) bool {
+		return infobases[i].Name < infobases[j].Name
+	})
+
+	return infobases
+}
+
+// InfobaseName возвращает имя информационной базы по ее каталогу
+func (d *ClusterDiscovery) InfobaseName(infobaseDir string) (string, bool) {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	name, ok := d.names[strings.ToLower(filepath.Base(infobaseDir))]
+	return name, ok
+}
+
+// Tag возвращает хранилище, которое заполняет имя информационной базы событий
+// и передает их в storage
+func (d *ClusterDiscovery) Tag(storage ExporterStorage) ExporterStorage {
+	return &infobaseTagger{
+		discovery: d,
+		next:      storage,
+	}
+}
+
+type infobaseTagger struct {
+	discovery *ClusterDiscovery
+	next      ExporterStorage
+}
+
+func (t *infobaseTagger) Push(event Event) {
+
+	if len(event.Infobase) == 0 {
+		if name, ok := t.discovery.InfobaseName(event.InfobaseDir); ok {
+			event.Infobase = name
+		}
+	}
+
+	t.next.Push(event)
+}
+
+func (t *infobaseTagger) Flush() error {
+	if f, ok := t.next.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func (t *infobaseTagger) Close() error {
+	if c, ok := t.next.(interface{ Close() error }); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// ReadClusterInfobases читает имена информационных баз по uuid из файла реестра кластера 1CV8Clst.lst.
+// Кластер перезаписывает файл на месте, поэтому для недописанного файла возвращается ошибка
+func ReadClusterInfobases(file string) (names map[string]string, err error) {
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if !completeBrackets(data) {
+		return nil, fmt.Errorf("%s: incomplete registry", file)
+	}
+
+	// Парсер завершается паникой на некорректных данных
+	defer func() {
+		if r := recover(); r != nil {
+			names, err = nil, fmt.Errorf("%s: invalid registry: %v", file, r)
+		}
+	}()
+
+	node, _ := brackets.NewParser(bytes.NewReader(data)).NextNode()
+	if node == nil {
+		return nil, fmt.Errorf("%s: incomplete registry", file)
+	}
+
+	names = make(map[string]string)
+	collectInfobaseNames(node, names)
+
+	return names, nil
+}
+
+// completeBrackets проверяет, что в данных есть объект и все скобки вне строк закрыты.
+// Кавычки внутри строк удваиваются, поэтому не нарушают подсчет
+func completeBrackets(data []byte) bool {
+
+	var (
+		depth   int
+		objects int
+		quoted  bool
+	)
+
+	for _, c := range data {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '{':
+			depth++
+			objects++
+		case c == '}':
+			depth--
+			if depth < 0 {
+				return false
+			}
+		}
+	}
+
+	return objects > 0 && depth == 0 && !quoted
+}
+
+// collectInfobaseNames ищет описания информационных баз вида {uuid,"имя",...}.
+// Описания кластера и серверов имеют такой же вид, но каталогов журналов для них нет
+func collectInfobaseNames(node brackets.Node, names map[string]string) {
+
+	first, err := node.GetNodeE(0)
+	if err != nil {
+		return
+	}
+
+	if uuid := strings.ToLower(first.Get()); isUuid(uuid) {
+		if _, ok := names[uuid]; !ok {
+			if name := unquote(node.Get(1)); len(name) > 0 {
+				names[uuid] = name
+			}
+		}
+	}
+
+	for i := 0; ; i++ {
+		child, err := node.GetNodeE(i)
+		if err != nil {
+			return
+		}
+		collectInfobaseNames(child, names)
+	}
+}
+
+// unquote убирает кавычки, которые парсер оставляет у строк с экранированными кавычками
+func unquote(value string) string {
+
+	if len(value) > 1 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+		value = strings.ReplaceAll(value[1:len(value)-1], `""`, `"`)
+	}
+
+	return value
+}
+
+func isUuid(value string) bool {
+
+	if len(value) != 36 {
+		return false
+	}
+
+	for i, c := range value {
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+				return false
+			}
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,232 @@
+package eventlog
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+const testClusterRegistry = "\xef\xbb\xbf{0,\r\n" +
+	`{3a0b6c0e-5f4d-4e0b-8f3c-1c2d3e4f5a6b,"Локальный кластер",1541,"srv1c",0,0,0,0,0,0,0,0},` + "\r\n" +
+	`{2,` + "\r\n" +
+	`{a8f8f5d2-9d1b-4c73-9c4f-5d1c7f0a1e11,"buh","Бухгалтерия ""Главная""","PostgreSQL","srv1c","buh","postgres","",0,0,"",0,0},` + "\r\n" +
+	`{b27e1d40-6c1a-4d5e-9f2b-7a8c9d0e1f23,"zup","","MSSQLServer","srv1c","zup","sa","",0,0,"",0,0}` + "\r\n" +
+	`},` + "\r\n" +
+	`{0}` + "\r\n" +
+	`}`
+
+type testWatcher struct {
+	watched map[string]bool
+}
+
+func (w *testWatcher) Watch(folder string) error {
+	w.watched[folder] = true
+	return nil
+}
+
+func (w *testWatcher) Unwatch(folder string) error {
+	delete(w.watched, folder)
+	return nil
+}
+
+func (w *testWatcher) folders() []string {
+	var folders []string
+	for folder := range w.watched {
+		folders = append(folders, folder)
+	}
+	sort.Strings(folders)
+	return folders
+}
+
+func TestReadClusterInfobases(t *testing.T) {
+
+	file := filepath.Join(t.TempDir(), clusterInfobasesFileName)
+	if err := ioutil.WriteFile(file, []byte(testClusterRegistry), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := ReadClusterInfobases(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if names["a8f8f5d2-9d1b-4c73-9c4f-5d1c7f0a1e11"] != "buh" || names["b27e1d40-6c1a-4d5e-9f2b-7a8c9d0e1f23"] != "zup" {
+		t.Errorf("names = %v", names)
+	}
+}
+
+func TestClusterDiscovery_Scan(t *testing.T) {
+
+	srvinfo := t.TempDir()
+	registry := filepath.Join(srvinfo, "reg_1541")
+
+	buh := filepath.Join(registry, "a8f8f5d2-9d1b-4c73-9c4f-5d1c7f0a1e11", journalDirName)
+	zup := filepath.Join(registry, "b27e1d40-6c1a-4d5e-9f2b-7a8c9d0e1f23", journalDirName)
+	unknown := filepath.Join(registry, "c0ffee00-0000-4000-8000-000000000001", journalDirName)
+
+	for _, dir := range []string{buh, zup, filepath.Join(registry, "snccntx")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(registry, clusterInfobasesFileName), []byte(testClusterRegistry), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	watcher := &testWatcher{watched: make(map[string]bool)}
+
+	d := NewClusterDiscovery(ClusterDiscoveryOptions{
+		Dir:     srvinfo,
+		Watcher: watcher,
+	})
+
+	if err := d.Scan(); err != nil {
+		t.Fatal(err)
+	}
+
+	if folders := watcher.folders(); len(folders) != 2 || folders[0] != buh || folders[1] != zup {
+		t.Fatalf("watched = %v", folders)
+	}
+
+	infobases := d.Infobases()
+	if len(infobases) != 2 || infobases[0].Name != "buh" || infobases[1].Name != "zup" {
+		t.Errorf("infobases = %v", infobases)
+	}
+
+	// Появилась новая база, которой еще нет в реестре, и удалена zup
+	if err := os.MkdirAll(unknown, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(filepath.Dir(zup)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.Scan(); err != nil {
+		t.Fatal(err)
+	}
+
+	if folders := watcher.folders(); len(folders) != 2 || folders[0] != buh || folders[1] != unknown {
+		t.Fatalf("watched after rescan = %v", folders)
+	}
+
+	// Реестр прочитан во время перезаписи, имена баз сохраняются
+	if err := ioutil.WriteFile(filepath.Join(registry, clusterInfobasesFileName), []byte(testClusterRegistry[:100]), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Scan(); err != nil {
+		t.Fatal(err)
+	}
+	if name, _ := d.InfobaseName(filepath.Dir(buh)); name != "buh" {
+		t.Errorf("name after partial registry = %s, want buh", name)
+	}
+
+	// События помечаются именем базы
+	storage := &collectStorage{}
+	tagger := d.Tag(storage)
+
+	tagger.Push(Event{InfobaseDir: filepath.Dir(buh)})
+	tagger.Push(Event{InfobaseDir: filepath.Dir(unknown)})
+	tagger.Push(Event{InfobaseDir: "/other/ib"})
+
+	if storage.events[0].Infobase != "buh" ||
+		storage.events[1].Infobase != "c0ffee00-0000-4000-8000-000000000001" ||
+		storage.events[2].Infobase != "" {
+		t.Errorf("tagged = %q %q %q", storage.events[0].Infobase, storage.events[1].Infobase, storage.events[2].Infobase)
+	}
+}
+
+func TestReadClusterInfobases_Truncated(t *testing.T) {
+
+	file := filepath.Join(t.TempDir(), clusterInfobasesFileName)
+
+	// Кластер перезаписывает файл на месте, файл может быть прочитан не полностью
+	for _, size := range []int{0, 3, 10, len(testClusterRegistry) / 2, len(testClusterRegistry) - 1} {
+		if err := ioutil.WriteFile(file, []byte(testClusterRegistry[:size]), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if names, err := ReadClusterInfobases(file); err == nil {
+			t.Errorf("size %d: names = %v, want error", size, names)
+		}
+	}
+}
+
+func TestClusterDiscovery_ScanManager(t *testing.T) {
+
+	journalDir, _, _ := copyTestJournal(t, -1)
+
+	srvinfo := t.TempDir()
+	infobase := filepath.Join(srvinfo, "reg_1541", "a8f8f5d2-9d1b-4c73-9c4f-5d1c7f0a1e11")
+	folder := filepath.Join(infobase, journalDirName)
+
+	if err := os.MkdirAll(infobase, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(journalDir, folder); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(srvinfo, "reg_1541", clusterInfobasesFileName), []byte(testClusterRegistry), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	d := NewClusterDiscovery(ClusterDiscoveryOptions{Dir: srvinfo})
+	storage := &deliveryStorage{delay: time.Millisecond}
+
+	// Имя базы заполняется в горутине очереди перед медленным хранилищем
+	m := NewManager(context.Background(), ManagerOptions{
+		PoolSize:  1,
+		BulkSize:  100,
+		Exporters: []ExporterStorage{d.Tag(storage)},
+		Notifier:  NewPollingNotifier(10 * time.Millisecond),
+		Backfill:  true,
+		Queue:     QueueOptions{Size: 10},
+	})
+	defer m.Stop()
+
+	d.watcher = m
+
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Scan(); err != nil {
+		t.Fatal(err)
+	}
+
+	file, _ := filepath.Abs(filepath.Join(folder, "20210108100000.lgp"))
+
+	deadline := time.Now().Add(10 * time.Second)
+	for storage.End(file) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if storage.End(file) == 0 {
+		t.Fatal("no events exported")
+	}
+
+	// База удалена, пока ее события еще в очереди
+	if err := os.RemoveAll(infobase); err != nil {
+		t.Fatal(err)
+	}
+
+	scanned := make(chan error, 1)
+	go func() {
+		scanned <- d.Scan()
+	}()
+
+	select {
+	case err := <-scanned:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Scan does not return while events of removed infobase are queued")
+	}
+
+	if infobases := d.Infobases(); len(infobases) != 0 {
+		t.Errorf("infobases after removal = %v", infobases)
+	}
+}
@@ -0,0 +1,33 @@
+// eventlogd служба выгрузки журналов регистрации 1С:Предприятие по файлу настроек.
+//
+// Настройки перечитываются по сигналу SIGHUP
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/v8platform/eventlog/daemon"
+)
+
+func main() {
+
+	config := flag.String("config", "eventlog.yaml", "файл настроек")
+	flag.Parse()
+
+	d, err := daemon.New(*config)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := d.Run(ctx); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,226 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/v8platform/eventlog"
+	"gopkg.in/yaml.v3"
+)
+
+// Config настройки службы выгрузки журналов регистрации.
+//
+//	tz: Europe/Moscow
+//	journal:
+//	  file: /var/lib/eventlog/offsets.json
+//	poller:
+//	  bulk_size: 500
+//	  timeout: 5s
+//	folders:
+//	  - path: /srv/1c/srvinfo/reg_1541
+//	    exporters:
+//	      - name: siem
+//	        type: syslog
+//	        network: tcp
+//	        address: siem.local:514
+//	        filter:
+//	          severity: [E, W]
+type Config struct {
+	TZ      string         `yaml:"tz"` // Временная зона сервера 1С, по умолчанию локальная
+	Journal JournalConfig  `yaml:"journal"`
+	Poller  PollerConfig   `yaml:"poller"`
+	Folders []FolderConfig `yaml:"folders"`
+}
+
+// JournalConfig хранилище позиций файлов журналов
+type JournalConfig struct {
+	File string `yaml:"file"` // Если не указан, то позиции хранятся только в памяти
+}
+
+// PollerConfig параметры чтения файлов журналов
+type PollerConfig struct {
+	PoolSize           int           `yaml:"pool_size"`
+	BulkSize           int           `yaml:"bulk_size"`
+	Timeout            time.Duration `yaml:"timeout"`
+	Ticker             time.Duration `yaml:"ticker"` // Период опроса каталогов, если нет уведомлений ОС
+	IdleCheckFrequency time.Duration `yaml:"idle_check_frequency"`
+	Backfill           bool          `yaml:"backfill"`
+	BackfillPoolSize   int           `yaml:"backfill_pool_size"`
+	RateLimit          float64       `yaml:"rate_limit"` // Чтений файла журнала в секунду
+}
+
+// FolderConfig отслеживаемый каталог и хранилища для событий его журналов
+type FolderConfig struct {
+	Path      string           `yaml:"path"`
+	Exporters []ExporterConfig `yaml:"exporters"`
+}
+
+// ExporterConfig хранилище событий. Набор полей зависит от типа хранилища
+type ExporterConfig struct {
+	Name   string       `yaml:"name"`
+	Type   string       `yaml:"type"` // http, syslog или elastic
+	Filter FilterConfig `yaml:"filter"`
+	Queue  QueueConfig  `yaml:"queue"`
+	Spool  SpoolConfig  `yaml:"spool"`
+
+	URL         string            `yaml:"url"`     // http, elastic
+	Network     string            `yaml:"network"` // syslog: udp, tcp или tls
+	Address     string            `yaml:"address"` // syslog
+	Headers     map[string]string `yaml:"headers"`
+	Username    string            `yaml:"username"`
+	Password    string            `yaml:"password"`
+	BearerToken string            `yaml:"bearer_token"`
+	APIKey      string            `yaml:"api_key"`
+	Gzip        bool              `yaml:"gzip"`
+	Index       string            `yaml:"index"`
+	Infobase    string            `yaml:"infobase"`
+	Hostname    string            `yaml:"hostname"`
+	AppName     string            `yaml:"app_name"`
+	Facility    int               `yaml:"facility"`
+
+	BatchSize      int           `yaml:"batch_size"`
+	FlushInterval  time.Duration `yaml:"flush_interval"`
+	MaxInFlight    int           `yaml:"max_in_flight"`
+	MaxRetries     int           `yaml:"max_retries"`
+	MinBackoff     time.Duration `yaml:"min_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff"`
+	DeadLetterFile string        `yaml:"dead_letter_file"`
+}
+
+// FilterConfig отбор событий для хранилища. Пустой список не ограничивает отбор
+type FilterConfig struct {
+	Severity     []string `yaml:"severity"` // I, E, W, N
+	Events       []string `yaml:"events"`
+	Applications []string `yaml:"applications"`
+	Users        []string `yaml:"users"`
+	Metadata     []string `yaml:"metadata"`
+}
+
+// QueueConfig очередь событий перед хранилищем
+type QueueConfig struct {
+	Size     int    `yaml:"size"`
+	Overflow string `yaml:"overflow"` // block, drop_oldest или spill, по умолчанию block
+	SpillDir string `yaml:"spill_dir"`
+}
+
+// SpoolConfig сохранение событий на диск на время недоступности хранилища
+type SpoolConfig struct {
+	Dir         string `yaml:"dir"` // Если не указан, то события на диск не сохраняются
+	SegmentSize int64  `yaml:"segment_size"`
+	MaxSize     int64  `yaml:"max_size"`
+}
+
+var overflowPolicies = map[string]eventlog.OverflowPolicy{
+	"":            eventlog.OverflowBlock,
+	"block":       eventlog.OverflowBlock,
+	"drop_oldest": eventlog.OverflowDropOldest,
+	"spill":       eventlog.OverflowSpill,
+}
+
+func (q QueueConfig) options() eventlog.QueueOptions {
+	return eventlog.QueueOptions{
+		Size:     q.Size,
+		Overflow: overflowPolicies[q.Overflow],
+		SpillDir: q.SpillDir,
+	}
+}
+
+var ErrNoFolders = errors.New("config: no folders")
+
+// LoadConfig читает и проверяет файл настроек
+func LoadConfig(file string) (*Config, error) {
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseConfig(data)
+}
+
+func ParseConfig(data []byte) (*Config, error) {
+
+	var cfg Config
+
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+func (c *Config) validate() error {
+
+	if len(c.Folders) == 0 {
+		return ErrNoFolders
+	}
+
+	if len(c.TZ) > 0 {
+		if _, err := time.LoadLocation(c.TZ); err != nil {
+			return fmt.Errorf("config: tz: %w", err)
+		}
+	}
+
+	for i := range c.Folders {
+
+		folder := &c.Folders[i]
+		if len(folder.Path) == 0 {
+			return fmt.Errorf("config: folder %d: empty path", i)
+		}
+
+		path, err := filepath.Abs(folder.Path)
+		if err != nil {
+			return fmt.Errorf("config: folder %s: %w", folder.Path, err)
+		}
+		folder.Path = path
+
+		for _, e := range folder.Exporters {
+			if err := e.validate(); err != nil {
+				return fmt.Errorf("config: folder %s: exporter %s: %w", folder.Path, e.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (e ExporterConfig) validate() error {
+
+	switch e.Type {
+	case "http", "elastic":
+		if len(e.URL) == 0 {
+			return errors.New("url is required")
+		}
+	case "syslog":
+		if len(e.Address) == 0 {
+			return errors.New("address is required")
+		}
+	default:
+		return fmt.Errorf("unknown type %q", e.Type)
+	}
+
+	if _, ok := overflowPolicies[e.Queue.Overflow]; !ok {
+		return fmt.Errorf("unknown queue overflow %q", e.Queue.Overflow)
+	}
+
+	return nil
+}
+
+// Location возвращает временную зону сервера 1С
+func (c *Config) Location() *time.Location {
+
+	if len(c.TZ) == 0 {
+		return time.Local
+	}
+
+	// Зона проверена при загрузке настроек
+	loc, _ := time.LoadLocation(c.TZ)
+	return loc
+}
@@ -0,0 +1,289 @@
+package daemon
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+
+	"github.com/v8platform/eventlog"
+)
+
+// Daemon служба выгрузки журналов регистрации по файлу настроек.
+//
+// При перечитывании настроек (Reload, сигнал SIGHUP) добавляются и удаляются отслеживаемые каталоги
+// и хранилища событий. Менеджер не перезапускается, поэтому позиции файлов не теряются.
+// Изменения tz, journal и poller применяются только после перезапуска
+type Daemon struct {
+	file    string
+	manager *eventlog.Manager
+	router  *router
+
+	mu        sync.Mutex
+	config    *Config
+	exporters map[string][]*runningExporter // Хранилища по каталогам
+}
+
+type runningExporter struct {
+	cfg     ExporterConfig
+	storage *eventlog.QueuedStorage
+}
+
+// New создает службу по файлу настроек. Выгрузка начинается после вызова Start или Run
+func New(file string) (*Daemon, error) {
+
+	cfg, err := LoadConfig(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var journal eventlog.JournalStorage
+	if len(cfg.Journal.File) > 0 {
+		if journal, err = eventlog.NewFileJournal(cfg.Journal.File); err != nil {
+			return nil, err
+		}
+	}
+
+	d := &Daemon{
+		file:   file,
+		router: &router{},
+	}
+
+	exporters, err := d.prepare(cfg)
+	if err != nil {
+		return nil, err
+	}
+	d.apply(cfg, exporters)
+
+	var folders []string
+	for _, folder := range cfg.Folders {
+		folders = append(folders, folder.Path)
+	}
+
+	d.manager = eventlog.NewManager(context.Background(), eventlog.ManagerOptions{
+		Timeout:            cfg.Poller.Timeout,
+		Folder:             folders,
+		PoolSize:           cfg.Poller.PoolSize,
+		IdleCheckFrequency: cfg.Poller.IdleCheckFrequency,
+		JournalStorage:     journal,
+		Exporters:          []eventlog.ExporterStorage{d.router},
+		BulkSize:           cfg.Poller.BulkSize,
+		TZ:                 cfg.Location(),
+		Backfill:           cfg.Poller.Backfill,
+		BackfillPoolSize:   cfg.Poller.BackfillPoolSize,
+		RateLimit:          cfg.Poller.RateLimit,
+	})
+	if cfg.Poller.Ticker > 0 {
+		d.manager.Ticker = cfg.Poller.Ticker
+	}
+
+	return d, nil
+}
+
+func (d *Daemon) Start() error {
+	return d.manager.Start()
+}
+
+// Run запускает выгрузку и перечитывает настройки по сигналу SIGHUP.
+// При завершении ctx служба останавливается, дождавшись фиксации позиций
+func (d *Daemon) Run(ctx context.Context) error {
+
+	if err := d.Start(); err != nil {
+		return err
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return d.Shutdown(context.Background())
+		case <-hup:
+			if err := d.Reload(); err != nil {
+				log.Printf("daemon: reload %s: %s", d.file, err)
+				continue
+			}
+			log.Printf("daemon: reloaded %s", d.file)
+		}
+	}
+}
+
+// Reload перечитывает файл настроек. При ошибке продолжают работать прежние настройки
+func (d *Daemon) Reload() error {
+
+	cfg, err := LoadConfig(d.file)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	old := d.config
+	d.mu.Unlock()
+
+	if cfg.TZ != old.TZ || cfg.Journal != old.Journal || cfg.Poller != old.Poller {
+		log.Printf("daemon: tz, journal and poller changes require restart")
+	}
+
+	exporters, err := d.prepare(cfg)
+	if err != nil {
+		return err
+	}
+
+	oldFolders := folderSet(old)
+	newFolders := folderSet(cfg)
+
+	// Выгрузка удаленных каталогов останавливается до замены хранилищ:
+	// прочитанные события передаются в прежние хранилища, а их позиции фиксируются
+	for folder := range oldFolders {
+		if _, ok := newFolders[folder]; ok {
+			continue
+		}
+		if err := d.manager.Unwatch(folder); err != nil {
+			log.Printf("daemon: unwatch %s: %s", folder, err)
+		}
+	}
+
+	d.apply(cfg, exporters)
+
+	for folder := range newFolders {
+		if _, ok := oldFolders[folder]; ok {
+			continue
+		}
+		if err := d.manager.Watch(folder); err != nil {
+			log.Printf("daemon: watch %s: %s", folder, err)
+		}
+	}
+
+	return nil
+}
+
+// prepare создает хранилища новых настроек. Хранилища с неизмененными настройками используются повторно
+func (d *Daemon) prepare(cfg *Config) (map[string][]*runningExporter, error) {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var (
+		exporters = make(map[string][]*runningExporter)
+		reused    = make(map[*runningExporter]bool)
+		created   []*runningExporter
+	)
+
+	for _, folder := range cfg.Folders {
+		for _, ecfg := range folder.Exporters {
+
+			e := findExporter(d.exporters[folder.Path], ecfg, reused)
+			if e == nil {
+				storage, err := newExporter(ecfg)
+				if err != nil {
+					for _, e := range created {
+						_ = closeExporter(e.storage)
+					}
+					return nil, err
+				}
+				e = &runningExporter{cfg: ecfg, storage: storage}
+				created = append(created, e)
+			}
+
+			reused[e] = true
+			exporters[folder.Path] = append(exporters[folder.Path], e)
+		}
+	}
+
+	return exporters, nil
+}
+
+// apply подключает хранилища новых настроек вместо прежних и закрывает неиспользуемые
+func (d *Daemon) apply(cfg *Config, exporters map[string][]*runningExporter) {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	used := make(map[*runningExporter]bool)
+	routes := make(map[string][]eventlog.ExporterStorage, len(exporters))
+	for folder, list := range exporters {
+		for _, e := range list {
+			used[e] = true
+			routes[folder] = append(routes[folder], e.storage)
+		}
+	}
+	d.router.set(routes)
+
+	for _, list := range d.exporters {
+		for _, e := range list {
+			if used[e] {
+				continue
+			}
+			if err := closeExporter(e.storage); err != nil {
+				log.Printf("daemon: close exporter %s: %s", e.cfg.Name, err)
+			}
+		}
+	}
+
+	d.config = cfg
+	d.exporters = exporters
+}
+
+func findExporter(list []*runningExporter, cfg ExporterConfig, reused map[*runningExporter]bool) *runningExporter {
+	for _, e := range list {
+		if !reused[e] && reflect.DeepEqual(e.cfg, cfg) {
+			return e
+		}
+	}
+	return nil
+}
+
+func folderSet(cfg *Config) map[string]struct{} {
+	folders := make(map[string]struct{}, len(cfg.Folders))
+	for _, folder := range cfg.Folders {
+		folders[folder.Path] = struct{}{}
+	}
+	return folders
+}
+
+// Stats возвращает состояние очередей хранилищ по каталогам в порядке настроек
+func (d *Daemon) Stats() map[string][]eventlog.QueueStats {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stats := make(map[string][]eventlog.QueueStats, len(d.exporters))
+	for folder, list := range d.exporters {
+		for _, e := range list {
+			stats[folder] = append(stats[folder], e.storage.Stats())
+		}
+	}
+
+	return stats
+}
+
+// Shutdown останавливает менеджер и закрывает хранилища после фиксации позиций
+func (d *Daemon) Shutdown(ctx context.Context) error {
+
+	if err := d.manager.Shutdown(ctx); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.router.set(nil)
+
+	var firstErr error
+
+	for _, list := range d.exporters {
+		for _, e := range list {
+			if err := closeExporter(e.storage); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	d.exporters = nil
+
+	return firstErr
+}
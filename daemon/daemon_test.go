@@ -0,0 +1,285 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/v8platform/eventlog"
+)
+
+func TestParseConfig(t *testing.T) {
+
+	cfg, err := ParseConfig([]byte(`
+tz: Europe/Moscow
+poller:
+  bulk_size: 500
+  timeout: 5s
+folders:
+  - path: logs
+    exporters:
+      - name: siem
+        type: syslog
+        address: siem.local:514
+        flush_interval: 2s
+        queue:
+          overflow: spill
+        filter:
+          severity: [E, W]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Poller.Timeout != 5*time.Second || cfg.Poller.BulkSize != 500 {
+		t.Errorf("poller = %+v", cfg.Poller)
+	}
+	if !filepath.IsAbs(cfg.Folders[0].Path) {
+		t.Errorf("folder path %s is not absolute", cfg.Folders[0].Path)
+	}
+	e := cfg.Folders[0].Exporters[0]
+	if e.FlushInterval != 2*time.Second || len(e.Filter.Severity) != 2 || e.Queue.options().Overflow != eventlog.OverflowSpill {
+		t.Errorf("exporter = %+v", e)
+	}
+	if cfg.Location().String() != "Europe/Moscow" {
+		t.Errorf("location = %s", cfg.Location())
+	}
+
+	bad := []string{
+		``,
+		"folders:\n  - path: logs\n    exporters:\n      - type: kafka\n",
+		"folders:\n  - path: logs\n    exporters:\n      - type: http\n",
+		"folders:\n  - path: logs\n    exporters:\n      - type: http\n        url: http://localhost\n        queue:\n          overflow: never\n",
+		"tz: Nowhere/City\nfolders:\n  - path: logs\n",
+	}
+	for _, data := range bad {
+		if _, err := ParseConfig([]byte(data)); err == nil {
+			t.Errorf("expected error for config %q", data)
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+
+	storage := &countStorage{}
+	f := newFilter(storage, FilterConfig{Severity: []string{"E"}, Users: []string{"Admin"}})
+
+	f.Push(eventlog.Event{Severity: eventlog.SeverityError, User: "Admin"})
+	f.Push(eventlog.Event{Severity: eventlog.SeverityInfo, User: "Admin"})
+	f.Push(eventlog.Event{Severity: eventlog.SeverityError, User: "User"})
+
+	if storage.n != 1 {
+		t.Errorf("filtered events = %d, want 1", storage.n)
+	}
+
+	if newFilter(storage, FilterConfig{}) != eventlog.ExporterStorage(storage) {
+		t.Error("empty filter must not wrap storage")
+	}
+}
+
+type countStorage struct {
+	n int
+}
+
+func (s *countStorage) Push(eventlog.Event) {
+	s.n++
+}
+
+// eventServer принимает пачки событий HTTP хранилища
+type eventServer struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	events int
+}
+
+func newEventServer(t *testing.T) *eventServer {
+
+	s := &eventServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var events []json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&events); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		s.events += len(events)
+		s.mu.Unlock()
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func (s *eventServer) waitEvents(t *testing.T) int {
+
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		n := s.events
+		s.mu.Unlock()
+		if n > 0 {
+			return n
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("no events received by %s", s.URL)
+	return 0
+}
+
+// journalFolder создает каталог с копией тестового журнала регистрации
+func journalFolder(t *testing.T) string {
+
+	dir := t.TempDir()
+
+	for _, name := range []string{"1Cv8.lgf", "20210108100000.lgp"} {
+		data, err := ioutil.ReadFile(filepath.Join("..", "tests", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func writeConfig(t *testing.T, file string, data string) {
+	if err := ioutil.WriteFile(file, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDaemon_Reload(t *testing.T) {
+
+	folderA := journalFolder(t)
+	folderB := journalFolder(t)
+	serverA := newEventServer(t)
+	serverB := newEventServer(t)
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "eventlog.yaml")
+	offsets := filepath.Join(dir, "offsets.json")
+
+	head := fmt.Sprintf(`
+journal:
+  file: %s
+poller:
+  ticker: 10ms
+  backfill: true
+folders:
+  - path: %s
+    exporters:
+      - type: http
+        url: %s
+        flush_interval: 10ms
+`, offsets, folderA, serverA.URL)
+
+	writeConfig(t, file, head)
+
+	d, err := New(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	serverA.waitEvents(t)
+	storageA := d.exporters[folderA][0].storage
+
+	// Добавляется каталог со своим хранилищем, хранилище первого каталога продолжает работать
+	writeConfig(t, file, head+fmt.Sprintf(`
+  - path: %s
+    exporters:
+      - type: http
+        url: %s
+        flush_interval: 10ms
+`, folderB, serverB.URL))
+
+	if err := d.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	serverB.waitEvents(t)
+
+	if d.exporters[folderA][0].storage != storageA {
+		t.Error("unchanged exporter recreated on reload")
+	}
+
+	if stats := d.Stats()[folderA]; len(stats) != 1 || stats[0].Delivered == 0 {
+		t.Errorf("folder stats = %+v, want delivered events", stats)
+	}
+
+	// Ошибочные настройки не применяются
+	writeConfig(t, file, "folders: []")
+	if err := d.Reload(); err == nil {
+		t.Error("expected reload error")
+	}
+	if len(d.exporters) != 2 {
+		t.Errorf("exporters of %d folders after failed reload, want 2", len(d.exporters))
+	}
+
+	// Shutdown останавливает чтение после текущей партии, поэтому ожидается выгрузка всего файла
+	fileB := filepath.Join(folderB, "20210108100000.lgp")
+	info, err := os.Stat(fileB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	savedOffset := func() int64 {
+		journal, err := eventlog.NewFileJournal(offsets)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return journal.GetOffset(fileB)
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for savedOffset() != info.Size() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := d.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Позиции сохранены в файл и не теряются после перезапуска
+	if off := savedOffset(); off != info.Size() {
+		t.Errorf("saved offset = %d, want %d", off, info.Size())
+	}
+}
+
+func TestNewExporter_Spool(t *testing.T) {
+
+	server := newEventServer(t)
+
+	storage, err := newExporter(ExporterConfig{
+		Type:  "http",
+		URL:   server.URL,
+		Spool: SpoolConfig{Dir: t.TempDir()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storage.Push(eventlog.Event{Comment: "spooled"})
+	server.waitEvents(t)
+
+	if err := storage.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,175 @@
+package daemon
+
+import (
+	"errors"
+
+	"github.com/v8platform/eventlog"
+	"github.com/v8platform/eventlog/exporter"
+	"github.com/xelaj/go-dry"
+)
+
+// newExporter создает хранилище событий по настройкам с учетом отбора.
+// События передаются в хранилище через собственную очередь
+func newExporter(cfg ExporterConfig) (*eventlog.QueuedStorage, error) {
+
+	var (
+		storage eventlog.ExporterStorage
+		err     error
+	)
+
+	switch cfg.Type {
+	case "http":
+		storage = exporter.NewHTTPExporter(exporter.HTTPOptions{
+			URL:            cfg.URL,
+			Headers:        cfg.Headers,
+			Username:       cfg.Username,
+			Password:       cfg.Password,
+			BearerToken:    cfg.BearerToken,
+			Gzip:           cfg.Gzip,
+			BatchSize:      cfg.BatchSize,
+			FlushInterval:  cfg.FlushInterval,
+			MaxInFlight:    cfg.MaxInFlight,
+			MaxRetries:     cfg.MaxRetries,
+			MinBackoff:     cfg.MinBackoff,
+			MaxBackoff:     cfg.MaxBackoff,
+			DeadLetterFile: cfg.DeadLetterFile,
+		})
+	case "elastic":
+		storage = exporter.NewElasticExporter(exporter.ElasticOptions{
+			URL:            cfg.URL,
+			Username:       cfg.Username,
+			Password:       cfg.Password,
+			APIKey:         cfg.APIKey,
+			Index:          cfg.Index,
+			Infobase:       cfg.Infobase,
+			BatchSize:      cfg.BatchSize,
+			FlushInterval:  cfg.FlushInterval,
+			MaxInFlight:    cfg.MaxInFlight,
+			MaxRetries:     cfg.MaxRetries,
+			MinBackoff:     cfg.MinBackoff,
+			MaxBackoff:     cfg.MaxBackoff,
+			DeadLetterFile: cfg.DeadLetterFile,
+		})
+	case "syslog":
+		storage, err = exporter.NewSyslogExporter(exporter.SyslogOptions{
+			Network:        cfg.Network,
+			Address:        cfg.Address,
+			Hostname:       cfg.Hostname,
+			AppName:        cfg.AppName,
+			Facility:       cfg.Facility,
+			BatchSize:      cfg.BatchSize,
+			FlushInterval:  cfg.FlushInterval,
+			MaxRetries:     cfg.MaxRetries,
+			MinBackoff:     cfg.MinBackoff,
+			MaxBackoff:     cfg.MaxBackoff,
+			DeadLetterFile: cfg.DeadLetterFile,
+		})
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cfg.Spool.Dir) > 0 {
+		if storage, err = newSpooled(storage, cfg.Spool); err != nil {
+			return nil, err
+		}
+	}
+
+	return eventlog.NewQueuedStorage(newFilter(storage, cfg.Filter), cfg.Queue.options()), nil
+}
+
+// spooled хранилище, события которого сначала сохраняются на диск
+type spooled struct {
+	*eventlog.Spool
+	storage eventlog.ExporterStorage
+}
+
+func newSpooled(storage eventlog.ExporterStorage, cfg SpoolConfig) (*spooled, error) {
+
+	writer, ok := storage.(eventlog.EventWriter)
+	if !ok {
+		return nil, errors.New("exporter does not support spool")
+	}
+
+	spool, err := eventlog.NewSpool(writer, eventlog.SpoolOptions{
+		Dir:         cfg.Dir,
+		SegmentSize: cfg.SegmentSize,
+		MaxSize:     cfg.MaxSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &spooled{Spool: spool, storage: storage}, nil
+}
+
+// Close останавливает передачу с диска и закрывает хранилище.
+// Непереданные события будут переданы после запуска службы
+func (s *spooled) Close() error {
+
+	err := s.Spool.Close()
+
+	if closeErr := closeExporter(s.storage); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// closeExporter отправляет оставшиеся события и освобождает ресурсы хранилища
+func closeExporter(storage eventlog.ExporterStorage) error {
+
+	switch s := storage.(type) {
+	case interface{ Close() error }:
+		return s.Close()
+	case interface{ Flush() error }:
+		return s.Flush()
+	}
+
+	return nil
+}
+
+// filter передает в хранилище только события, подходящие под отбор
+type filter struct {
+	next eventlog.ExporterStorage
+	cfg  FilterConfig
+}
+
+func newFilter(next eventlog.ExporterStorage, cfg FilterConfig) eventlog.ExporterStorage {
+
+	if len(cfg.Severity)+len(cfg.Events)+len(cfg.Applications)+len(cfg.Users)+len(cfg.Metadata) == 0 {
+		return next
+	}
+
+	return &filter{next: next, cfg: cfg}
+}
+
+func (f *filter) Push(event eventlog.Event) {
+	if f.Match(event) {
+		f.next.Push(event)
+	}
+}
+
+func (f *filter) Match(event eventlog.Event) bool {
+	return match(f.cfg.Severity, string(event.Severity)) &&
+		match(f.cfg.Events, string(event.Event)) &&
+		match(f.cfg.Applications, string(event.Application)) &&
+		match(f.cfg.Users, event.User) &&
+		match(f.cfg.Metadata, event.Metadata)
+}
+
+func match(values []string, value string) bool {
+	return len(values) == 0 || dry.StringListContains(values, value)
+}
+
+func (f *filter) Flush() error {
+	if s, ok := f.next.(interface{ Flush() error }); ok {
+		return s.Flush()
+	}
+	return nil
+}
+
+func (f *filter) Close() error {
+	return closeExporter(f.next)
+}
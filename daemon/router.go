@@ -0,0 +1,67 @@
+package daemon
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/v8platform/eventlog"
+)
+
+var _ eventlog.ExporterStorage = (*router)(nil)
+
+// router передает события в хранилища каталога, из которого прочитан файл журнала.
+// Набор каталогов и хранилищ заменяется при перечитывании настроек без остановки менеджера
+type router struct {
+	mu      sync.RWMutex
+	folders map[string][]eventlog.ExporterStorage
+}
+
+func (r *router) Push(event eventlog.Event) {
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for folder, storages := range r.folders {
+		if !inFolder(event.JournalFile, folder) {
+			continue
+		}
+		for _, s := range storages {
+			s.Push(event)
+		}
+	}
+}
+
+// Flush отправляет накопленные события всех хранилищ
+func (r *router) Flush() error {
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var firstErr error
+
+	for _, storages := range r.folders {
+		for _, s := range storages {
+			f, ok := s.(interface{ Flush() error })
+			if !ok {
+				continue
+			}
+			if err := f.Flush(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return firstErr
+}
+
+func (r *router) set(folders map[string][]eventlog.ExporterStorage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.folders = folders
+}
+
+func inFolder(file, folder string) bool {
+	return strings.HasPrefix(file, folder+string(filepath.Separator))
+}
@@ -0,0 +1,266 @@
+package eventlog
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Watermark позиция за последним доставленным событием журнала регистрации
+type Watermark struct {
+	File   string `json:"file"`   // Имя файла журнала без каталога
+	Offset int64  `json:"offset"` // Смещение за последним доставленным событием
+}
+
+// Delivered проверяет, что событие файла позиции находится до нее.
+// События других файлов позицией не проверяются, т.к. файлы одного журнала
+// могут выгружаться одновременно
+func (w Watermark) Delivered(event Event) bool {
+	return filepath.Base(event.JournalFile) == w.File && event.Offset < w.Offset
+}
+
+// watermarkKey ключ позиции файла журнала
+func watermarkKey(journalUUID, file string) string {
+	return journalUUID + "/" + filepath.Base(file)
+}
+
+// WatermarkStorage хранит позиции доставленных событий по UUID журналов и именам файлов
+type WatermarkStorage interface {
+	Load() (map[string]Watermark, error)
+	Save(watermarks map[string]Watermark) error
+}
+
+var _ WatermarkStorage = (*FileWatermarkStorage)(nil)
+
+// FileWatermarkStorage хранит позиции в JSON файле
+type FileWatermarkStorage struct {
+	File string
+}
+
+func (s *FileWatermarkStorage) Load() (map[string]Watermark, error) {
+
+	watermarks := make(map[string]Watermark)
+
+	data, err := ioutil.ReadFile(s.File)
+	if os.IsNotExist(err) {
+		return watermarks, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &watermarks); err != nil {
+		return nil, err
+	}
+
+	return watermarks, nil
+}
+
+// Save записывает позиции во временный файл и заменяет им старый,
+// чтобы сбой во время записи не повредил сохраненные позиции
+func (s *FileWatermarkStorage) Save(watermarks map[string]Watermark) error {
+
+	data, err := json.Marshal(watermarks)
+	if err != nil {
+		return err
+	}
+
+	tmp := s.File + ".tmp"
+
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, s.File)
+}
+
+type DeduplicatorOptions struct {
+	// Storage хранилище позиций. Если не указано, то позиции хранятся только в памяти
+	Storage WatermarkStorage
+
+	// SaveInterval период сохранения позиций, по умолчанию 5 секунд.
+	// Перед сохранением вызывается Flush хранилища событий, если оно его поддерживает
+	SaveInterval time.Duration
+}
+
+var _ ExporterStorage = (*Deduplicator)(nil)
+
+// Deduplicator пропускает в хранилище только события, которые в него еще не передавались.
+//
+// Для каждого файла журнала регистрации хранится позиция за последним переданным событием.
+// Позиция переданных событий становится постоянной только после успешного Flush хранилища,
+// при ошибке она возвращается к прежней, чтобы повторно прочитанные события были переданы снова.
+// Позиция только увеличивается. События без JournalUUID передаются без проверки
+type Deduplicator struct {
+	next         ExporterStorage
+	storage      WatermarkStorage
+	saveInterval time.Duration
+
+	mu         sync.Mutex
+	watermarks map[string]Watermark // Подтвержденные Flush хранилища
+	pending    map[string]Watermark // Переданные, но еще не подтвержденные
+	dirty      bool
+	saved      time.Time
+}
+
+// NewDeduplicator создает фильтр повторных событий перед хранилищем next
+// и загружает сохраненные позиции
+func NewDeduplicator(next ExporterStorage, opts DeduplicatorOptions) (*Deduplicator, error) {
+
+	if opts.SaveInterval <= 0 {
+		opts.SaveInterval = 5 * time.Second
+	}
+
+	d := &Deduplicator{
+		next:         next,
+		storage:      opts.Storage,
+		saveInterval: opts.SaveInterval,
+		watermarks:   make(map[string]Watermark),
+		pending:      make(map[string]Watermark),
+		saved:        time.Now(),
+	}
+
+	if d.storage != nil {
+		watermarks, err := d.storage.Load()
+		if err != nil {
+			return nil, err
+		}
+		d.watermarks = watermarks
+	}
+
+	return d, nil
+}
+
+func (d *Deduplicator) Push(event Event) {
+
+	if len(event.JournalUUID) == 0 {
+		d.next.Push(event)
+		return
+	}
+
+	d.mu.Lock()
+
+	key := watermarkKey(event.JournalUUID, event.JournalFile)
+
+	watermark, ok := d.pending[key]
+	if !ok {
+		watermark, ok = d.watermarks[key]
+	}
+	if ok && watermark.Delivered(event) {
+		d.mu.Unlock()
+		return
+	}
+
+	if end := event.Offset + event.Size; end > watermark.Offset {
+		d.pending[key] = Watermark{
+			File:   filepath.Base(event.JournalFile),
+			Offset: end,
+		}
+	}
+
+	save := time.Since(d.saved) >= d.saveInterval
+
+	d.mu.Unlock()
+
+	d.next.Push(event)
+
+	if save {
+		if err := d.Flush(); err != nil {
+			log.Printf("deduplicator: save watermarks: %s", err)
+		}
+	}
+}
+
+// Watermark возвращает подтвержденную позицию файла журнала
+func (d *Deduplicator) Watermark(journalUUID, file string) (Watermark, bool) {
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	watermark, ok := d.watermarks[watermarkKey(journalUUID, file)]
+	return watermark, ok
+}
+
+// Flush вызывает Flush хранилища событий и сохраняет позиции.
+// Позиции переданных событий подтверждаются только после успешной передачи событий в хранилище,
+// при ошибке они отменяются
+func (d *Deduplicator) Flush() error {
+
+	d.mu.Lock()
+
+	pending := make(map[string]Watermark, len(d.pending))
+	for key, watermark := range d.pending {
+		pending[key] = watermark
+	}
+	d.saved = time.Now()
+
+	d.mu.Unlock()
+
+	if f, ok := d.next.(interface{ Flush() error }); ok {
+		if err := f.Flush(); err != nil {
+			d.mu.Lock()
+			for key := range pending {
+				delete(d.pending, key)
+			}
+			d.mu.Unlock()
+			return err
+		}
+	}
+
+	d.mu.Lock()
+
+	for key, watermark := range pending {
+		if watermark.Offset > d.watermarks[key].Offset {
+			d.watermarks[key] = watermark
+			d.dirty = true
+		}
+		// Позиция могла сдвинуться во время Flush
+		if d.pending[key] == watermark {
+			delete(d.pending, key)
+		}
+	}
+
+	watermarks := make(map[string]Watermark, len(d.watermarks))
+	for key, watermark := range d.watermarks {
+		watermarks[key] = watermark
+	}
+	dirty := d.dirty
+	d.dirty = false
+
+	d.mu.Unlock()
+
+	if !dirty || d.storage == nil {
+		return nil
+	}
+
+	if err := d.storage.Save(watermarks); err != nil {
+		d.markDirty()
+		return err
+	}
+
+	return nil
+}
+
+func (d *Deduplicator) markDirty() {
+	d.mu.Lock()
+	d.dirty = true
+	d.mu.Unlock()
+}
+
+// Close сохраняет позиции и закрывает хранилище событий, если оно это поддерживает
+func (d *Deduplicator) Close() error {
+
+	if err := d.Flush(); err != nil {
+		return err
+	}
+
+	if c, ok := d.next.(interface{ Close() error }); ok {
+		return c.Close()
+	}
+
+	return nil
+}
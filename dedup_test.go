@@ -0,0 +1,241 @@
+package eventlog
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type collectStorage struct {
+	events  []Event
+	flushes int
+}
+
+func (s *collectStorage) Push(event Event) {
+	s.events = append(s.events, event)
+}
+
+func (s *collectStorage) Flush() error {
+	s.flushes++
+	return nil
+}
+
+// unreliableStorage хранилище, Flush которого возвращает ошибку, пока установлен err
+type unreliableStorage struct {
+	collectStorage
+	err error
+}
+
+func (s *unreliableStorage) Flush() error {
+	s.flushes++
+	return s.err
+}
+
+func readTestEvents(t *testing.T, offset int64) []Event {
+
+	reader, err := NewLgpReader("./tests/20210108100000.lgp", LgpReaderOptions{
+		Offset:   offset,
+		Location: time.UTC,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	events, err := reader.Read(100, 0)
+	if err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+
+	return events
+}
+
+func TestDeduplicator(t *testing.T) {
+
+	events := readTestEvents(t, 0)
+	if len(events) != 100 {
+		t.Fatalf("read %d events", len(events))
+	}
+
+	if events[0].JournalUUID == "" || events[0].JournalFile != "./tests/20210108100000.lgp" {
+		t.Fatalf("journal not set: <%s> <%s>", events[0].JournalUUID, events[0].JournalFile)
+	}
+
+	file := filepath.Join(t.TempDir(), "watermarks.json")
+
+	next := &collectStorage{}
+	d, err := NewDeduplicator(next, DeduplicatorOptions{
+		Storage: &FileWatermarkStorage{File: file},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, event := range events[:60] {
+		d.Push(event)
+	}
+
+	// Повторное чтение с начала файла
+	for _, event := range events {
+		d.Push(event)
+	}
+
+	if len(next.events) != 100 {
+		t.Fatalf("delivered %d events, want 100", len(next.events))
+	}
+
+	if err := d.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if next.flushes == 0 {
+		t.Error("storage not flushed")
+	}
+
+	// После перезапуска события до сохраненной позиции не передаются
+	next = &collectStorage{}
+	d, err = NewDeduplicator(next, DeduplicatorOptions{
+		Storage: &FileWatermarkStorage{File: file},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	watermark, ok := d.Watermark(events[0].JournalUUID, events[0].JournalFile)
+	last := events[len(events)-1]
+	if !ok || watermark.File != "20210108100000.lgp" || watermark.Offset != last.Offset+last.Size {
+		t.Errorf("watermark = %v", watermark)
+	}
+
+	for _, event := range events[50:] {
+		d.Push(event)
+	}
+
+	for _, event := range readTestEvents(t, watermark.Offset)[:10] {
+		d.Push(event)
+	}
+
+	if len(next.events) != 10 || next.events[0].Offset != watermark.Offset {
+		t.Errorf("delivered %d events after restart", len(next.events))
+	}
+}
+
+func TestWatermark_Delivered(t *testing.T) {
+
+	watermark := Watermark{File: "20210108100000.lgp", Offset: 200}
+
+	tests := []struct {
+		file   string
+		offset int64
+		want   bool
+	}{
+		{"/ib/1Cv8Log/20210108100000.lgp", 100, true},
+		{"/ib/1Cv8Log/20210108100000.lgp", 200, false},
+		{"/ib/1Cv8Log/20210101000000.lgp", 5000, false},
+		{"/ib/1Cv8Log/20210109000000.lgp", 10, false},
+	}
+
+	for _, tt := range tests {
+		if got := watermark.Delivered(Event{JournalFile: tt.file, Offset: tt.offset}); got != tt.want {
+			t.Errorf("Delivered(%s, %d) = %v, want %v", tt.file, tt.offset, got, tt.want)
+		}
+	}
+}
+
+func TestDeduplicator_InterleavedFiles(t *testing.T) {
+
+	next := &collectStorage{}
+	d, err := NewDeduplicator(next, DeduplicatorOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event := func(file string, offset int64) Event {
+		return Event{
+			JournalUUID: "5e9a7aa8-4efa-11e9-a98f-005056aea130",
+			JournalFile: "/ib/1Cv8Log/" + file,
+			Offset:      offset,
+			Size:        100,
+		}
+	}
+
+	const (
+		older = "20210108100000.lgp"
+		newer = "20210108110000.lgp"
+	)
+
+	// Старый файл дочитывается одновременно с записью в новый
+	events := []Event{
+		event(older, 0),
+		event(newer, 0),
+		event(older, 100),
+		event(newer, 100),
+		event(older, 200),
+		event(older, 100), // повтор
+		event(newer, 0),   // повтор
+		event(older, 300),
+	}
+
+	for _, e := range events {
+		d.Push(e)
+	}
+
+	if len(next.events) != 6 {
+		t.Errorf("delivered %d events, want 6", len(next.events))
+	}
+
+	if err := d.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if w, _ := d.Watermark(events[0].JournalUUID, older); w.Offset != 400 {
+		t.Errorf("older watermark = %+v, want offset 400", w)
+	}
+	if w, _ := d.Watermark(events[0].JournalUUID, newer); w.Offset != 200 {
+		t.Errorf("newer watermark = %+v, want offset 200", w)
+	}
+}
+
+func TestDeduplicator_FlushError(t *testing.T) {
+
+	events := readTestEvents(t, 0)
+
+	next := &unreliableStorage{err: errors.New("broker not available")}
+	d, err := NewDeduplicator(next, DeduplicatorOptions{
+		Storage: &FileWatermarkStorage{File: filepath.Join(t.TempDir(), "watermarks.json")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, event := range events[:50] {
+		d.Push(event)
+	}
+
+	if err := d.Flush(); err == nil {
+		t.Fatal("Flush() error = nil")
+	}
+	if _, ok := d.Watermark(events[0].JournalUUID, events[0].JournalFile); ok {
+		t.Error("watermark moved without delivery")
+	}
+
+	// Позиция не зафиксирована, файл читается повторно
+	next.err = nil
+	for _, event := range events {
+		d.Push(event)
+	}
+
+	if len(next.events) != 150 {
+		t.Errorf("delivered %d events, want 150", len(next.events))
+	}
+
+	if err := d.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	last := events[len(events)-1]
+	if w, _ := d.Watermark(last.JournalUUID, last.JournalFile); w.Offset != last.Offset+last.Size {
+		t.Errorf("watermark = %+v, want offset %d", w, last.Offset+last.Size)
+	}
+}
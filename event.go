@@ -2,7 +2,9 @@ package eventlog
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"path/filepath"
 	"time"
 )
 
@@ -24,7 +26,8 @@ type seekReader interface {
 }
 
 type Event struct {
-	Date              time.Time
+	Date              time.Time // Время события в зоне сервера
+	DateUTC           time.Time // Время события по UTC
 	TransactionStatus TransactionStatusType
 	TransactionDate   time.Time
 	TransactionNumber int64
@@ -46,10 +49,26 @@ type Event struct {
 	Session           int64
 	//SessionDataSeparators []
 
-	Offset      int64
-	Size        int64
-	JournalFile string
-	JournalUUID string
+	Offset         int64
+	Size           int64
+	JournalFile    string // Файл журнала регистрации, из которого прочитано событие
+	JournalUUID    string // Идентификатор журнала регистрации из заголовка файла
+	JournalVersion string // Версия формата файла журнала регистрации
+	InfobaseDir    string // Каталог информационной базы
+	Infobase       string // Имя информационной базы, если известно
+}
+
+// Key возвращает ключ события, уникальный в пределах журнала регистрации.
+// Ключ состоит из UUID журнала, имени файла и смещения события в файле,
+// поэтому повторное чтение того же события дает тот же ключ
+func (e Event) Key() string {
+
+	var file string
+	if len(e.JournalFile) > 0 {
+		file = filepath.Base(e.JournalFile)
+	}
+
+	return fmt.Sprintf("%s:%s:%d", e.JournalUUID, file, e.Offset)
 }
 
 type Objects interface {
@@ -77,8 +96,9 @@ func (m *EventManager) Stop() {
 	m.stop <- empty
 }
 
-func (m *EventManager) Poll(dest chan Event, stop chan struct{}) {
+func (m *EventManager) Poll(ctx context.Context, dest chan<- Event) error {
 	if m.Poller == nil {
 		panic("manager: can't start without a poller")
 	}
+	return m.Poller.Poll(ctx, m.reader, dest)
 }
@@ -0,0 +1,219 @@
+// Package eventlogpb содержит protobuf схему событий журнала регистрации
+// и gRPC сервис потоковой передачи событий.
+package eventlogpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative eventlog.proto
+
+import (
+	"encoding/json"
+
+	"github.com/v8platform/eventlog"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var transactionStatuses = map[eventlog.TransactionStatusType]TransactionStatus{
+	eventlog.TransactionStatusCommitted:     TransactionStatus_TRANSACTION_STATUS_COMMITTED,
+	eventlog.TransactionStatusCanceled:      TransactionStatus_TRANSACTION_STATUS_CANCELED,
+	eventlog.TransactionStatusNotCompleted:  TransactionStatus_TRANSACTION_STATUS_NOT_COMPLETED,
+	eventlog.TransactionStatusNoTransaction: TransactionStatus_TRANSACTION_STATUS_NO_TRANSACTION,
+}
+
+var severities = map[eventlog.SeverityType]Severity{
+	eventlog.SeverityInfo:  Severity_SEVERITY_INFO,
+	eventlog.SeverityError: Severity_SEVERITY_ERROR,
+	eventlog.SeverityWarn:  Severity_SEVERITY_WARN,
+	eventlog.SeverityNote:  Severity_SEVERITY_NOTE,
+}
+
+var applications = map[eventlog.ApplicationType]Application{
+	eventlog.Application1CV8:                Application_APPLICATION_1CV8,
+	eventlog.Application1CV8C:               Application_APPLICATION_1CV8C,
+	eventlog.ApplicationWebClient:           Application_APPLICATION_WEB_CLIENT,
+	eventlog.ApplicationDesigner:            Application_APPLICATION_DESIGNER,
+	eventlog.ApplicationCOMConnection:       Application_APPLICATION_COM_CONNECTION,
+	eventlog.ApplicationWSConnection:        Application_APPLICATION_WS_CONNECTION,
+	eventlog.ApplicationBackgroundJob:       Application_APPLICATION_BACKGROUND_JOB,
+	eventlog.ApplicationSystemBackgroundJob: Application_APPLICATION_SYSTEM_BACKGROUND_JOB,
+	eventlog.ApplicationSrvrConsole:         Application_APPLICATION_SRVR_CONSOLE,
+	eventlog.ApplicationCOMConsole:          Application_APPLICATION_COM_CONSOLE,
+	eventlog.ApplicationJobScheduler:        Application_APPLICATION_JOB_SCHEDULER,
+	eventlog.ApplicationDebugger:            Application_APPLICATION_DEBUGGER,
+	eventlog.ApplicationRAS:                 Application_APPLICATION_RAS,
+}
+
+func FromTransactionStatus(status eventlog.TransactionStatusType) TransactionStatus {
+	return transactionStatuses[status]
+}
+
+func ToTransactionStatus(status TransactionStatus) eventlog.TransactionStatusType {
+	for k, v := range transactionStatuses {
+		if v == status {
+			return k
+		}
+	}
+	return ""
+}
+
+func FromSeverity(severity eventlog.SeverityType) Severity {
+	return severities[severity]
+}
+
+func ToSeverity(severity Severity) eventlog.SeverityType {
+	for k, v := range severities {
+		if v == severity {
+			return k
+		}
+	}
+	return ""
+}
+
+func FromApplication(application eventlog.ApplicationType) Application {
+	return applications[application]
+}
+
+func ToApplication(application Application) eventlog.ApplicationType {
+	for k, v := range applications {
+		if v == application {
+			return k
+		}
+	}
+	return ""
+}
+
+// FromEvent переводит событие журнала в protobuf сообщение
+func FromEvent(event eventlog.Event) (*Event, error) {
+
+	data, err := fromData(event.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	pb := &Event{
+		TransactionStatus: FromTransactionStatus(event.TransactionStatus),
+		TransactionNumber: event.TransactionNumber,
+		UserUuid:          event.UserUuid,
+		User:              event.User,
+		Computer:          event.Computer,
+		Application:       FromApplication(event.Application),
+		ApplicationName:   string(event.Application),
+		Connection:        event.Connection,
+		Event:             string(event.Event),
+		Severity:          FromSeverity(event.Severity),
+		Comment:           event.Comment,
+		MetadataUuid:      event.MetadataUuid,
+		Metadata:          event.Metadata,
+		Data:              data,
+		DataPresentation:  event.DataPresentation,
+		Server:            event.Server,
+		MainPort:          event.MainPort,
+		AddPort:           event.AddPort,
+		Session:           event.Session,
+		Offset:            event.Offset,
+		Size:              event.Size,
+		JournalFile:       event.JournalFile,
+		JournalUuid:       event.JournalUUID,
+		JournalVersion:    event.JournalVersion,
+		InfobaseDir:       event.InfobaseDir,
+		Infobase:          event.Infobase,
+	}
+
+	if !event.Date.IsZero() {
+		pb.Date = timestamppb.New(event.Date)
+	}
+
+	if !event.TransactionDate.IsZero() {
+		pb.TransactionDate = timestamppb.New(event.TransactionDate)
+	}
+
+	return pb, nil
+}
+
+// ToEvent переводит protobuf сообщение в событие журнала. Время событий возвращается по UTC
+func ToEvent(pb *Event) eventlog.Event {
+
+	event := eventlog.Event{
+		TransactionStatus: ToTransactionStatus(pb.GetTransactionStatus()),
+		TransactionNumber: pb.GetTransactionNumber(),
+		UserUuid:          pb.GetUserUuid(),
+		User:              pb.GetUser(),
+		Computer:          pb.GetComputer(),
+		Application:       eventlog.ApplicationType(pb.GetApplicationName()),
+		Connection:        pb.GetConnection(),
+		Event:             eventlog.EventType(pb.GetEvent()),
+		Severity:          ToSeverity(pb.GetSeverity()),
+		Comment:           pb.GetComment(),
+		MetadataUuid:      pb.GetMetadataUuid(),
+		Metadata:          pb.GetMetadata(),
+		DataPresentation:  pb.GetDataPresentation(),
+		Server:            pb.GetServer(),
+		MainPort:          pb.GetMainPort(),
+		AddPort:           pb.GetAddPort(),
+		Session:           pb.GetSession(),
+		Offset:            pb.GetOffset(),
+		Size:              pb.GetSize(),
+		JournalFile:       pb.GetJournalFile(),
+		JournalUUID:       pb.GetJournalUuid(),
+		JournalVersion:    pb.GetJournalVersion(),
+		InfobaseDir:       pb.GetInfobaseDir(),
+		Infobase:          pb.GetInfobase(),
+	}
+
+	if len(event.Application) == 0 {
+		event.Application = ToApplication(pb.GetApplication())
+	}
+
+	if pb.Date != nil {
+		event.Date = pb.Date.AsTime()
+		event.DateUTC = event.Date
+	}
+
+	if pb.TransactionDate != nil {
+		event.TransactionDate = pb.TransactionDate.AsTime()
+	}
+
+	if pb.Data != nil {
+		event.Data = pb.Data.AsInterface()
+	}
+
+	return event
+}
+
+// fromData переводит данные события в значение protobuf.
+// Структуры данных переводятся через JSON
+func fromData(data interface{}) (*structpb.Value, error) {
+
+	if data == nil {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, err
+	}
+
+	return structpb.NewValue(value)
+}
+
+// Encoder кодирует события в protobuf для экспортеров сообщений
+type Encoder struct{}
+
+func (Encoder) Encode(event eventlog.Event) ([]byte, error) {
+
+	pb, err := FromEvent(event)
+	if err != nil {
+		return nil, err
+	}
+
+	return proto.Marshal(pb)
+}
+
+func (Encoder) ContentType() string {
+	return "application/x-protobuf"
+}
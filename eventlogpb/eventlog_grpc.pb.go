@@ -0,0 +1,130 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package eventlogpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// EventLogClient is the client API for EventLog service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type EventLogClient interface {
+	// StreamEvents передает события журналов регистрации
+	StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (EventLog_StreamEventsClient, error)
+}
+
+type eventLogClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEventLogClient(cc grpc.ClientConnInterface) EventLogClient {
+	return &eventLogClient{cc}
+}
+
+func (c *eventLogClient) StreamEvents(ctx context.Context, in *StreamEventsRequest, opts ...grpc.CallOption) (EventLog_StreamEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &EventLog_ServiceDesc.Streams[0], "/eventlog.v1.EventLog/StreamEvents", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &eventLogStreamEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type EventLog_StreamEventsClient interface {
+	Recv() (*StreamEventsResponse, error)
+	grpc.ClientStream
+}
+
+type eventLogStreamEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *eventLogStreamEventsClient) Recv() (*StreamEventsResponse, error) {
+	m := new(StreamEventsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// EventLogServer is the server API for EventLog service.
+// All implementations must embed UnimplementedEventLogServer
+// for forward compatibility
+type EventLogServer interface {
+	// StreamEvents передает события журналов регистрации
+	StreamEvents(*StreamEventsRequest, EventLog_StreamEventsServer) error
+	mustEmbedUnimplementedEventLogServer()
+}
+
+// UnimplementedEventLogServer must be embedded to have forward compatible implementations.
+type UnimplementedEventLogServer struct {
+}
+
+func (UnimplementedEventLogServer) StreamEvents(*StreamEventsRequest, EventLog_StreamEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamEvents not implemented")
+}
+func (UnimplementedEventLogServer) mustEmbedUnimplementedEventLogServer() {}
+
+// UnsafeEventLogServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to EventLogServer will
+// result in compilation errors.
+type UnsafeEventLogServer interface {
+	mustEmbedUnimplementedEventLogServer()
+}
+
+func RegisterEventLogServer(s grpc.ServiceRegistrar, srv EventLogServer) {
+	s.RegisterService(&EventLog_ServiceDesc, srv)
+}
+
+func _EventLog_StreamEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EventLogServer).StreamEvents(m, &eventLogStreamEventsServer{stream})
+}
+
+type EventLog_StreamEventsServer interface {
+	Send(*StreamEventsResponse) error
+	grpc.ServerStream
+}
+
+type eventLogStreamEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *eventLogStreamEventsServer) Send(m *StreamEventsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// EventLog_ServiceDesc is the grpc.ServiceDesc for EventLog service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var EventLog_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "eventlog.v1.EventLog",
+	HandlerType: (*EventLogServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamEvents",
+			Handler:       _EventLog_StreamEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "eventlog.proto",
+}
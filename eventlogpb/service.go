@@ -0,0 +1,276 @@
+package eventlogpb
+
+import (
+	"io"
+	"log"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/v8platform/eventlog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultServiceBuffer  = 1000
+	serviceReplayBulkSize = 1000
+)
+
+type ServiceOptions struct {
+	// Folders каталоги журналов регистрации, из которых дочитываются события по курсору клиента
+	Folders  []string
+	Location *time.Location // Временная зона сервера для дочитываемых событий, по умолчанию time.Local
+
+	// Buffer размер очереди событий клиента, по умолчанию 1000.
+	// Клиент, не успевающий забирать события, отключается и переподключается со своим курсором
+	Buffer int
+}
+
+var (
+	_ eventlog.ExporterStorage = (*Service)(nil)
+	_ EventLogServer           = (*Service)(nil)
+)
+
+// Service gRPC сервис потоковой передачи событий.
+//
+// Сервис подключается к Manager как хранилище и раздает новые события клиентам.
+// Клиент передает курсоры, полученные вместе с событиями, и после переподключения
+// сначала получает пропущенные события из файлов журнала, а затем новые
+type Service struct {
+	UnimplementedEventLogServer
+
+	opts ServiceOptions
+
+	mu          sync.Mutex
+	subscribers map[*subscriber]struct{}
+}
+
+type subscriber struct {
+	events  chan eventlog.Event
+	dropped chan struct{}
+}
+
+func NewService(opts ServiceOptions) *Service {
+
+	if opts.Buffer <= 0 {
+		opts.Buffer = defaultServiceBuffer
+	}
+
+	return &Service{
+		opts:        opts,
+		subscribers: make(map[*subscriber]struct{}),
+	}
+}
+
+// Push передает событие всем подключенным клиентам
+func (s *Service) Push(event eventlog.Event) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for sub := range s.subscribers {
+		select {
+		case sub.events <- event:
+		default:
+			delete(s.subscribers, sub)
+			close(sub.dropped)
+		}
+	}
+}
+
+func (s *Service) subscribe() *subscriber {
+
+	sub := &subscriber{
+		events:  make(chan eventlog.Event, s.opts.Buffer),
+		dropped: make(chan struct{}),
+	}
+
+	s.mu.Lock()
+	s.subscribers[sub] = struct{}{}
+	s.mu.Unlock()
+
+	return sub
+}
+
+func (s *Service) unsubscribe(sub *subscriber) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subscribers[sub]; ok {
+		delete(s.subscribers, sub)
+		close(sub.dropped)
+	}
+}
+
+// position последнее переданное клиенту место журнала
+type position struct {
+	file   string // Имя файла журнала без каталога
+	offset int64  // Смещение за последним переданным событием
+}
+
+// delivered проверяет, что событие уже передано клиенту
+func (p position) delivered(event eventlog.Event) bool {
+
+	file := filepath.Base(event.JournalFile)
+
+	if file != p.file {
+		// Имена файлов журнала упорядочены по времени создания
+		return file < p.file
+	}
+
+	return event.Offset < p.offset
+}
+
+func (s *Service) StreamEvents(req *StreamEventsRequest, stream EventLog_StreamEventsServer) error {
+
+	// Подписка оформляется до чтения файлов, чтобы не потерять события,
+	// записанные во время чтения. Повторы отбрасываются по позиции
+	sub := s.subscribe()
+	defer s.unsubscribe(sub)
+
+	positions := make(map[string]position)
+
+	for _, cursor := range req.GetCursors() {
+
+		pos, err := s.replay(cursor, stream)
+		if err != nil {
+			return err
+		}
+
+		positions[cursor.GetJournalUuid()] = pos
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-sub.dropped:
+			return status.Error(codes.ResourceExhausted, "eventlog: client is too slow, reconnect with the last cursor")
+		case event := <-sub.events:
+
+			if pos, ok := positions[event.JournalUUID]; ok {
+				if pos.delivered(event) {
+					continue
+				}
+				delete(positions, event.JournalUUID)
+			}
+
+			if err := send(stream, event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// replay передает события журнала, записанные после позиции курсора
+func (s *Service) replay(cursor *Cursor, stream EventLog_StreamEventsServer) (position, error) {
+
+	pos := position{
+		file:   filepath.Base(cursor.GetJournalFile()),
+		offset: cursor.GetOffset(),
+	}
+
+	folder, files, err := s.journal(cursor.GetJournalUuid())
+	if err != nil {
+		return pos, err
+	}
+
+	for _, file := range files {
+
+		name := filepath.Base(file)
+		if name < pos.file {
+			continue
+		}
+
+		offset := int64(0)
+		if name == pos.file {
+			offset = pos.offset
+		}
+
+		reader, err := eventlog.NewLgpReader(file, eventlog.LgpReaderOptions{
+			LgfDir:   folder,
+			Offset:   offset,
+			Location: s.opts.Location,
+		})
+		if err != nil {
+			return pos, status.Errorf(codes.Internal, "eventlog: open journal file: %s", err)
+		}
+
+		err = func() error {
+			defer reader.Close()
+
+			for {
+				events, err := reader.ReadCtx(stream.Context(), serviceReplayBulkSize, 0)
+				if err != nil && err != io.EOF {
+					return err
+				}
+
+				for _, event := range events {
+
+					if err := send(stream, event); err != nil {
+						return err
+					}
+
+					pos = position{file: name, offset: event.Offset + event.Size}
+				}
+
+				if err == io.EOF {
+					return nil
+				}
+			}
+		}()
+
+		if err != nil {
+			return pos, err
+		}
+	}
+
+	return pos, nil
+}
+
+// journal находит каталог журнала регистрации по идентификатору журнала
+func (s *Service) journal(uuid string) (string, []string, error) {
+
+	for _, folder := range s.opts.Folders {
+
+		files, err := eventlog.JournalFiles(folder)
+		if err != nil || len(files) == 0 {
+			continue
+		}
+
+		reader, err := eventlog.NewLgpReader(files[0], eventlog.LgpReaderOptions{LgfDir: folder})
+		if err != nil {
+			log.Printf("eventlog service: open journal file <%s>: %s", files[0], err)
+			continue
+		}
+		journalUuid := reader.Uuid
+		_ = reader.Close()
+
+		if journalUuid == uuid {
+			return folder, files, nil
+		}
+	}
+
+	return "", nil, status.Errorf(codes.NotFound, "eventlog: journal <%s> not found", uuid)
+}
+
+func send(stream EventLog_StreamEventsServer, event eventlog.Event) error {
+
+	// Событие не пропускается: поток завершается, и клиент продолжит с последнего полученного курсора
+	pb, err := FromEvent(event)
+	if err != nil {
+		log.Printf("eventlog service: convert event <%s>: %s", event.Key(), err)
+		return status.Errorf(codes.Internal, "eventlog: convert event <%s>: %s", event.Key(), err)
+	}
+
+	return stream.Send(&StreamEventsResponse{
+		Event: pb,
+		Cursor: &Cursor{
+			JournalUuid: event.JournalUUID,
+			JournalFile: filepath.Base(event.JournalFile),
+			Offset:      event.Offset + event.Size,
+		},
+	})
+}
@@ -0,0 +1,247 @@
+package eventlogpb
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/v8platform/eventlog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+func newTestClient(t *testing.T, service *Service) (EventLogClient, func()) {
+
+	listener := bufconn.Listen(1 << 20)
+
+	server := grpc.NewServer()
+	RegisterEventLogServer(server, service)
+	go server.Serve(listener)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return listener.Dial()
+		}),
+		grpc.WithInsecure(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return NewEventLogClient(conn), func() {
+		conn.Close()
+		server.Stop()
+	}
+}
+
+func readTestJournal(t *testing.T) []eventlog.Event {
+
+	reader, err := eventlog.NewLgpReader("../tests/20210108100000.lgp", eventlog.LgpReaderOptions{
+		Location: time.UTC,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	var events []eventlog.Event
+	for {
+		items, err := reader.Read(1000, 0)
+		if err != nil && err != io.EOF {
+			t.Fatal(err)
+		}
+		events = append(events, items...)
+		if err == io.EOF {
+			break
+		}
+	}
+	if len(events) < 3 {
+		t.Fatalf("test journal contains %d events", len(events))
+	}
+
+	for i := range events {
+		events[i].JournalFile = "../tests/20210108100000.lgp"
+		events[i].JournalUUID = reader.Uuid
+	}
+
+	return events
+}
+
+func TestConvert_RoundTrip(t *testing.T) {
+
+	events := readTestJournal(t)
+
+	for _, event := range events {
+
+		pb, err := FromEvent(event)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := proto.Marshal(pb)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		decoded := &Event{}
+		if err := proto.Unmarshal(data, decoded); err != nil {
+			t.Fatal(err)
+		}
+
+		got := ToEvent(decoded)
+
+		if !got.Date.Equal(event.Date) || got.Offset != event.Offset || got.Key() != event.Key() {
+			t.Errorf("event <%s> decoded as <%s> %s", event.Key(), got.Key(), got.Date)
+		}
+		if got.Severity != event.Severity || got.Application != event.Application ||
+			got.TransactionStatus != event.TransactionStatus || got.Event != event.Event {
+			t.Errorf("event <%s> enums = %s %s %s %s", event.Key(), got.Severity, got.Application, got.TransactionStatus, got.Event)
+		}
+		if got.User != event.User || got.Session != event.Session || got.Metadata != event.Metadata {
+			t.Errorf("event <%s> fields = %s %d %s", event.Key(), got.User, got.Session, got.Metadata)
+		}
+	}
+}
+
+func TestService_StreamEventsResume(t *testing.T) {
+
+	events := readTestJournal(t)
+
+	service := NewService(ServiceOptions{
+		Folders:  []string{"../tests"},
+		Location: time.UTC,
+	})
+
+	client, closeClient := newTestClient(t, service)
+	defer closeClient()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Клиент уже получил первое событие
+	stream, err := client.StreamEvents(ctx, &StreamEventsRequest{
+		Cursors: []*Cursor{{
+			JournalUuid: events[0].JournalUUID,
+			JournalFile: "20210108100000.lgp",
+			Offset:      events[0].Offset + events[0].Size,
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cursor *Cursor
+
+	for i := 1; i < len(events); i++ {
+
+		resp, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.Event.Offset != events[i].Offset {
+			t.Fatalf("event %d offset = %d, want %d", i, resp.Event.Offset, events[i].Offset)
+		}
+
+		cursor = resp.Cursor
+	}
+
+	if cursor.Offset != events[len(events)-1].Offset+events[len(events)-1].Size {
+		t.Errorf("cursor offset = %d", cursor.Offset)
+	}
+
+	// Повторно прочитанное событие не передается, новое передается
+	service.Push(events[len(events)-1])
+
+	next := events[len(events)-1]
+	next.Offset = cursor.Offset
+	next.Comment = "new event"
+	service.Push(next)
+
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Event.Offset != next.Offset || resp.Event.Comment != "new event" {
+		t.Errorf("live event = %d %s", resp.Event.Offset, resp.Event.Comment)
+	}
+}
+
+func TestService_StreamEventsConvertError(t *testing.T) {
+
+	events := readTestJournal(t)
+	last := events[len(events)-1]
+
+	service := NewService(ServiceOptions{
+		Folders:  []string{"../tests"},
+		Location: time.UTC,
+	})
+
+	client, closeClient := newTestClient(t, service)
+	defer closeClient()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Клиент получил все события, кроме последнего
+	prev := events[len(events)-2]
+	stream, err := client.StreamEvents(ctx, &StreamEventsRequest{
+		Cursors: []*Cursor{{
+			JournalUuid: prev.JournalUUID,
+			JournalFile: "20210108100000.lgp",
+			Offset:      prev.Offset + prev.Size,
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Подписка оформлена до передачи прочитанных событий
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Событие, которое нельзя передать, не пропускается
+	broken := last
+	broken.Offset = last.Offset + last.Size
+	broken.Data = make(chan int)
+
+	next := broken
+	next.Offset = broken.Offset + 1
+	next.Data = nil
+
+	service.Push(broken)
+	service.Push(next)
+
+	resp, err := stream.Recv()
+	if status.Code(err) != codes.Internal {
+		t.Errorf("Recv() = %v, %v, want %s error", resp, err, codes.Internal)
+	}
+}
+
+func TestService_StreamEventsUnknownJournal(t *testing.T) {
+
+	service := NewService(ServiceOptions{
+		Folders: []string{"../tests"},
+	})
+
+	client, closeClient := newTestClient(t, service)
+	defer closeClient()
+
+	stream, err := client.StreamEvents(context.Background(), &StreamEventsRequest{
+		Cursors: []*Cursor{{JournalUuid: "unknown"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := stream.Recv(); err == nil {
+		t.Error("expected error for unknown journal")
+	}
+}
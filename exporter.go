@@ -1,16 +1,33 @@
 package eventlog
 
-import "time"
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const defaultBulkSize = 1000
 
 type ExporterStorage interface {
 	Push(event Event)
 }
 
+type locationSetter interface {
+	SetLocation(loc *time.Location)
+}
+
 type ExporterConfig struct {
 	TZ      *time.Location // Временная зона для времени логово
 	Timeout time.Duration  // timeout чтения
 	Poller  Poller         // Читатель данных из файлов журнала регистрации
 
+	// Context контекст чтения. При его отмене чтение останавливается как при вызове Stop,
+	// но передачу событий в хранилища и позицию файла фиксирует вызов Stop
+	Context context.Context
 }
 
 func NewExporter(eventReader EventReader, storage []ExporterStorage, config ...ExporterConfig) *Exporter {
@@ -24,6 +41,10 @@ func NewExporter(eventReader EventReader, storage []ExporterStorage, config ...E
 	tz := time.Local
 	if cfg.TZ != nil {
 		tz = cfg.TZ
+
+		if r, ok := eventReader.(locationSetter); ok {
+			r.SetLocation(tz)
+		}
 	}
 	timeout := 1 * time.Second
 	if cfg.Timeout > 0 {
@@ -31,6 +52,12 @@ func NewExporter(eventReader EventReader, storage []ExporterStorage, config ...E
 	}
 	poller := cfg.Poller
 
+	parent := cfg.Context
+	if parent == nil {
+		parent = context.Background()
+	}
+	ctx, cancel := context.WithCancel(parent)
+
 	exporter := &Exporter{
 		TZ:          tz,
 		Timeout:     timeout,
@@ -38,7 +65,9 @@ func NewExporter(eventReader EventReader, storage []ExporterStorage, config ...E
 		Poller:      poller,
 		eventReader: eventReader,
 		storage:     storage,
-		stop:        make(chan struct{}),
+		ctx:         ctx,
+		cancel:      cancel,
+		done:        make(chan struct{}),
 	}
 
 	return exporter
@@ -55,49 +84,81 @@ type Exporter struct {
 	eventReader EventReader
 
 	storage []ExporterStorage
-	stop    chan struct{}
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	started bool
+	stopped bool
+	done    chan struct{}
 }
 
-//LiveMode bool          // Чтение онлайн данных
-func (e *Exporter) Start() {
+// Start читает события до конца файла или вызова Stop и передает их в хранилища.
+// Возвращает ошибку чтения. Остановка через Stop ошибкой не считается
+func (e *Exporter) Start() error {
 
 	if e.Poller == nil {
 		panic("exporter: can't start without a poller")
 	}
-	finished := make(chan struct{})
-	stop := make(chan struct{})
-	go e.Poller.Poll(e.eventReader, e.Events, stop)
 
-	for {
-		select {
-		// handle incoming updates
-		case event, closed := <-e.Events:
-			if !closed {
-				close(finished)
-				close(stop)
-				return
-			}
-			e.process(event)
-		// call to stop polling
-		case <-e.stop:
-			close(finished)
-			close(stop)
-
-			// TODO Надо дочитать последню партию событий
-			// TODO зафиксировать текущую позицию или не надо т.к. это можно зафиксировать на уровень уже
-			return
-		}
+	e.mu.Lock()
+	if e.started || e.stopped {
+		e.mu.Unlock()
+		return nil
 	}
+	e.started = true
+	e.mu.Unlock()
 
-}
+	defer close(e.done)
+
+	polled := make(chan error, 1)
+	go func() {
+		// Поллер закрывает канал событий после передачи последней прочитанной партии
+		polled <- e.Poller.Poll(e.ctx, e.eventReader, e.Events)
+	}()
+
+	for event := range e.Events {
+		e.process(event)
+	}
+
+	err := <-polled
+	if errors.Is(err, context.Canceled) && e.ctx.Err() != nil {
+		return nil
+	}
 
-// Stop gracefully shuts the poller down.
-func (e *Exporter) Stop() error {
-	e.stop <- struct{}{}
-	err := e.eventReader.Close()
 	return err
 }
 
+// Stop останавливает чтение, дожидается передачи прочитанных событий в хранилища,
+// вызывает Flush хранилищ, если они его поддерживают, и возвращает зафиксированную позицию файла.
+// Читатель событий не закрывается, им владеет вызывающий код
+func (e *Exporter) Stop() (int64, error) {
+
+	e.mu.Lock()
+	e.stopped = true
+	started := e.started
+	e.mu.Unlock()
+
+	e.cancel()
+
+	if started {
+		<-e.done
+	}
+
+	var firstErr error
+
+	for _, storage := range e.storage {
+		if f, ok := storage.(interface{ Flush() error }); ok {
+			if err := f.Flush(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	return e.eventReader.Offset(), firstErr
+}
+
 func (e *Exporter) process(event Event) {
 
 	for _, storage := range e.storage {
@@ -105,3 +166,67 @@ func (e *Exporter) process(event Event) {
 	}
 
 }
+
+// JournalFiles возвращает файлы журнала регистрации каталога, упорядоченные по времени создания
+func JournalFiles(folder string) ([]string, error) {
+
+	files, err := filepath.Glob(filepath.Join(folder, "*.lgp"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Имя файла журнала содержит время начала записи в него
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// ExportFolder читает все файлы журнала регистрации каталога от старых к новым
+// и передает события в хранилища. Используется для обработки архива журнала без Manager
+func ExportFolder(folder string, storage []ExporterStorage, opts ...LgpReaderOptions) error {
+
+	options := defaultOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	options.LgfDir = folder
+
+	files, err := JournalFiles(folder)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if err := exportFile(file, storage, options); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func exportFile(file string, storage []ExporterStorage, options LgpReaderOptions) error {
+
+	reader, err := NewLgpReader(file, options)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	for {
+		events, err := reader.Read(defaultBulkSize, 0)
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		for _, event := range events {
+			for _, s := range storage {
+				s.Push(event)
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package exporter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/v8platform/eventlog"
+)
+
+const (
+	defaultBatchSize     = 100
+	defaultFlushInterval = time.Second
+	defaultMaxInFlight   = 4
+	defaultMaxRetries    = 5
+	defaultMinBackoff    = 500 * time.Millisecond
+	defaultMaxBackoff    = 30 * time.Second
+)
+
+// batcher накапливает события в пачки и отправляет их не более чем в maxInFlight потоков.
+// Неполная пачка отправляется по истечении interval
+type batcher struct {
+	size     int
+	interval time.Duration
+	send     func(events []eventlog.Event) error
+
+	inFlight chan struct{}
+
+	mu      sync.Mutex
+	buf     []eventlog.Event
+	err     error
+	dropped int // Событий, добавленных после Close, с прошлого вызова Flush
+	closed  bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newBatcher(size int, interval time.Duration, maxInFlight int, send func(events []eventlog.Event) error) *batcher {
+
+	if size <= 0 {
+		size = defaultBatchSize
+	}
+	if interval <= 0 {
+		interval = defaultFlushInterval
+	}
+	if maxInFlight <= 0 {
+		maxInFlight = defaultMaxInFlight
+	}
+
+	b := &batcher{
+		size:     size,
+		interval: interval,
+		send:     send,
+		inFlight: make(chan struct{}, maxInFlight),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	go b.loop()
+
+	return b
+}
+
+func (b *batcher) loop() {
+
+	defer close(b.done)
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+			b.mu.Lock()
+			batch := b.take()
+			b.mu.Unlock()
+			b.dispatch(batch)
+		}
+	}
+}
+
+// Push добавляет событие в пачку. Если все потоки отправки заняты, то ожидает освобождения.
+// События, добавленные после Close, не отправляются, а Flush возвращает ошибку
+func (b *batcher) Push(event eventlog.Event) {
+
+	b.mu.Lock()
+
+	if b.closed {
+		b.dropped++
+		b.mu.Unlock()
+		log.Printf("exporter: push after close, event <%s> dropped", event.Key())
+		return
+	}
+
+	b.buf = append(b.buf, event)
+
+	var batch []eventlog.Event
+	if len(b.buf) >= b.size {
+		batch = b.take()
+	}
+
+	b.mu.Unlock()
+
+	b.dispatch(batch)
+}
+
+// Flush отправляет неполную пачку, ожидает завершения всех отправок
+// и возвращает первую ошибку отправки с прошлого вызова Flush
+func (b *batcher) Flush() error {
+
+	b.mu.Lock()
+	batch := b.take()
+	b.mu.Unlock()
+
+	b.dispatch(batch)
+	b.wait()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	err := b.err
+	b.err = nil
+
+	if b.dropped > 0 && err == nil {
+		err = fmt.Errorf("exporter: %d events pushed after close dropped", b.dropped)
+	}
+	b.dropped = 0
+
+	return err
+}
+
+// Close останавливает отправку по таймеру и отправляет оставшиеся события
+func (b *batcher) Close() error {
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return b.Flush()
+	}
+	b.closed = true
+	b.mu.Unlock()
+
+	close(b.stop)
+	<-b.done
+
+	return b.Flush()
+}
+
+func (b *batcher) take() []eventlog.Event {
+
+	if len(b.buf) == 0 {
+		return nil
+	}
+
+	batch := b.buf
+	b.buf = nil
+
+	return batch
+}
+
+func (b *batcher) dispatch(batch []eventlog.Event) {
+
+	if len(batch) == 0 {
+		return
+	}
+
+	b.inFlight <- struct{}{}
+
+	go func() {
+		defer func() { <-b.inFlight }()
+
+		if err := b.send(batch); err != nil {
+			b.mu.Lock()
+			if b.err == nil {
+				b.err = err
+			}
+			b.mu.Unlock()
+		}
+	}()
+}
+
+// wait ожидает завершения всех начатых отправок, занимая все потоки отправки
+func (b *batcher) wait() {
+
+	for i := 0; i < cap(b.inFlight); i++ {
+		b.inFlight <- struct{}{}
+	}
+
+	for i := 0; i < cap(b.inFlight); i++ {
+		<-b.inFlight
+	}
+}
+
+// permanentError ошибка, при которой повторная отправка не имеет смысла
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// retry выполняет fn до успешного выполнения с экспоненциально растущей паузой между попытками
+func retry(retries int, minBackoff, maxBackoff time.Duration, fn func() error) error {
+
+	backoff := minBackoff
+
+	for attempt := 0; ; attempt++ {
+
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		var permanent *permanentError
+		if errors.As(err, &permanent) || attempt >= retries {
+			return err
+		}
+
+		time.Sleep(backoff)
+
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// deadLetter дописывает в файл пачки событий, которые не удалось отправить, по одной пачке на строку
+type deadLetter struct {
+	mu   sync.Mutex
+	file string
+}
+
+func (d *deadLetter) Write(events []eventlog.Event) error {
+
+	if d == nil || len(d.file) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	f, err := os.OpenFile(d.file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
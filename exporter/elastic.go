@@ -0,0 +1,421 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/v8platform/eventlog"
+)
+
+const defaultElasticIndex = "eventlog-{infobase}-{yyyy.MM.dd}"
+
+type ElasticOptions struct {
+	URL      string // Адрес кластера, например http://localhost:9200
+	Username string
+	Password string
+	APIKey   string
+
+	// Index шаблон имени индекса, по умолчанию eventlog-{infobase}-{yyyy.MM.dd}.
+	// Поддерживаются {infobase}, {yyyy.MM.dd}, {yyyy.MM}, {yyyy}, {MM}, {dd}.
+	// Дата берется по UTC
+	Index string
+
+	// Infobase имя информационной базы для шаблона индекса.
+	// Если не указано, то используется имя каталога журнала регистрации события
+	Infobase string
+
+	BatchSize     int
+	FlushInterval time.Duration
+	MaxInFlight   int
+
+	MaxRetries int
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// DeadLetterFile файл для событий, отклоненных кластером или не отправленных после всех попыток
+	DeadLetterFile string
+
+	Client *http.Client
+}
+
+var (
+	_ eventlog.ExporterStorage = (*ElasticExporter)(nil)
+	_ eventlog.EventWriter     = (*ElasticExporter)(nil)
+)
+
+// ElasticExporter записывает события в Elasticsearch/OpenSearch через _bulk API.
+// Идентификатор документа формируется из ключа события, поэтому повторная выгрузка не создает дублей
+type ElasticExporter struct {
+	opts       ElasticOptions
+	client     *http.Client
+	batcher    *batcher
+	deadLetter *deadLetter
+}
+
+func NewElasticExporter(opts ElasticOptions) *ElasticExporter {
+
+	if len(opts.Index) == 0 {
+		opts.Index = defaultElasticIndex
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = defaultMaxRetries
+	}
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = defaultMinBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = defaultMaxBackoff
+	}
+
+	opts.URL = strings.TrimRight(opts.URL, "/")
+
+	e := &ElasticExporter{
+		opts:       opts,
+		client:     opts.Client,
+		deadLetter: &deadLetter{file: opts.DeadLetterFile},
+	}
+
+	if e.client == nil {
+		e.client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	e.batcher = newBatcher(opts.BatchSize, opts.FlushInterval, opts.MaxInFlight, e.send)
+
+	return e
+}
+
+func (e *ElasticExporter) Push(event eventlog.Event) {
+	e.batcher.Push(event)
+}
+
+func (e *ElasticExporter) Flush() error {
+	return e.batcher.Flush()
+}
+
+func (e *ElasticExporter) Close() error {
+	return e.batcher.Close()
+}
+
+// indexNameReplacer заменяет символы, недопустимые в имени индекса
+var indexNameReplacer = strings.NewReplacer(
+	"\\", "_", "/", "_", "*", "_", "?", "_", "\"", "_", "<", "_", ">", "_",
+	"|", "_", " ", "_", ",", "_", "#", "_", ":", "_",
+)
+
+// IndexName возвращает имя индекса для события по шаблону.
+// Имя приводится к нижнему регистру, недопустимые символы заменяются на "_",
+// а символы "_", "-" и "+" в начале имени удаляются
+func (e *ElasticExporter) IndexName(event eventlog.Event) string {
+
+	date := event.DateUTC
+	if date.IsZero() {
+		date = event.Date.UTC()
+	}
+
+	infobase := e.opts.Infobase
+	if len(infobase) == 0 {
+		infobase = eventInfobaseName(event)
+	}
+
+	replacer := strings.NewReplacer(
+		"{infobase}", infobase,
+		"{yyyy.MM.dd}", date.Format("2006.01.02"),
+		"{yyyy.MM}", date.Format("2006.01"),
+		"{yyyy}", date.Format("2006"),
+		"{MM}", date.Format("01"),
+		"{dd}", date.Format("02"),
+	)
+
+	name := indexNameReplacer.Replace(strings.ToLower(replacer.Replace(e.opts.Index)))
+
+	return strings.TrimLeft(name, "_-+")
+}
+
+// DocumentID возвращает идентификатор документа события.
+// Если файл журнала события не известен, то к смещению добавляются время и сеанс события,
+// чтобы события разных файлов одной базы не получили одинаковый идентификатор
+func (e *ElasticExporter) DocumentID(event eventlog.Event) string {
+
+	if len(event.JournalFile) > 0 {
+		return event.Key()
+	}
+
+	return fmt.Sprintf("%s:%d:%d", event.Date.Format("20060102150405"), event.Session, event.Offset)
+}
+
+// IndexTemplate возвращает шаблон индекса с описанием полей события
+func (e *ElasticExporter) IndexTemplate() map[string]interface{} {
+
+	pattern := e.opts.Index
+	for strings.Contains(pattern, "{") {
+		start := strings.Index(pattern, "{")
+		end := strings.Index(pattern[start:], "}")
+		if end == -1 {
+			break
+		}
+		pattern = pattern[:start] + "*" + pattern[start+end+1:]
+	}
+
+	return map[string]interface{}{
+		"index_patterns": []string{pattern},
+		"template": map[string]interface{}{
+			"mappings": map[string]interface{}{
+				"properties": eventMappings(),
+			},
+		},
+	}
+}
+
+// PutIndexTemplate создает или обновляет шаблон индекса в кластере
+func (e *ElasticExporter) PutIndexTemplate(name string) error {
+
+	data, err := json.Marshal(e.IndexTemplate())
+	if err != nil {
+		return err
+	}
+
+	resp, err := e.do(http.MethodPut, "/_index_template/"+name, "application/json", data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("elastic: put index template: unexpected status <%s>", resp.Status)
+	}
+
+	return nil
+}
+
+// eventMappings описывает поля события для индекса по их типам
+func eventMappings() map[string]interface{} {
+
+	properties := make(map[string]interface{})
+
+	eventType := reflect.TypeOf(eventlog.Event{})
+	timeType := reflect.TypeOf(time.Time{})
+
+	for i := 0; i < eventType.NumField(); i++ {
+
+		field := eventType.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		var mapping map[string]interface{}
+
+		switch {
+		case field.Name == "Data":
+			// Данные события имеют разную структуру для разных событий
+			mapping = map[string]interface{}{"type": "object", "enabled": false}
+		case field.Name == "Comment" || field.Name == "DataPresentation":
+			mapping = map[string]interface{}{"type": "text"}
+		case field.Type == timeType:
+			mapping = map[string]interface{}{"type": "date"}
+		case field.Type.Kind() == reflect.Int64 || field.Type.Kind() == reflect.Int:
+			mapping = map[string]interface{}{"type": "long"}
+		case field.Type.Kind() == reflect.Bool:
+			mapping = map[string]interface{}{"type": "boolean"}
+		case field.Type.Kind() == reflect.String:
+			mapping = map[string]interface{}{"type": "keyword"}
+		default:
+			mapping = map[string]interface{}{"type": "object", "enabled": false}
+		}
+
+		properties[field.Name] = mapping
+	}
+
+	return properties
+}
+
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int             `json:"status"`
+		Error  json.RawMessage `json:"error"`
+	} `json:"items"`
+}
+
+func (e *ElasticExporter) send(events []eventlog.Event) error {
+
+	pending := events
+	var rejected []eventlog.Event
+
+	err := retry(e.opts.MaxRetries, e.opts.MinBackoff, e.opts.MaxBackoff, func() error {
+
+		failed, denied, err := e.bulk(pending)
+		if err != nil {
+			return err
+		}
+
+		rejected = append(rejected, denied...)
+		pending = failed
+
+		if len(pending) > 0 {
+			return fmt.Errorf("elastic: %d documents not indexed", len(pending))
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		rejected = append(rejected, pending...)
+	}
+
+	if len(rejected) == 0 {
+		return nil
+	}
+
+	if err == nil {
+		err = fmt.Errorf("elastic: %d documents rejected", len(rejected))
+	}
+
+	log.Printf("elastic exporter: %s", err)
+
+	if dlErr := e.deadLetter.Write(rejected); dlErr != nil {
+		log.Printf("elastic exporter: write dead letter: %s", dlErr)
+	}
+
+	return err
+}
+
+// WriteEvents отправляет пачку событий одним запросом _bulk без повторных попыток.
+// Отклоненные кластером документы записываются в DeadLetterFile
+func (e *ElasticExporter) WriteEvents(events []eventlog.Event) error {
+
+	failed, rejected, err := e.bulk(events)
+	if err != nil {
+		return err
+	}
+
+	if len(rejected) > 0 {
+		log.Printf("elastic exporter: %d documents rejected", len(rejected))
+		if dlErr := e.deadLetter.Write(rejected); dlErr != nil {
+			log.Printf("elastic exporter: write dead letter: %s", dlErr)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("elastic: %d documents not indexed", len(failed))
+	}
+
+	return nil
+}
+
+// bulk отправляет события и возвращает события для повторной отправки и отклоненные кластером
+func (e *ElasticExporter) bulk(events []eventlog.Event) (failed, rejected []eventlog.Event, err error) {
+
+	body := &bytes.Buffer{}
+	enc := json.NewEncoder(body)
+
+	for _, event := range events {
+
+		action := map[string]interface{}{
+			"index": map[string]string{
+				"_index": e.IndexName(event),
+				"_id":    e.DocumentID(event),
+			},
+		}
+
+		if err := enc.Encode(action); err != nil {
+			return nil, nil, &permanentError{err}
+		}
+		if err := enc.Encode(event); err != nil {
+			return nil, nil, &permanentError{err}
+		}
+	}
+
+	resp, err := e.do(http.MethodPost, "/_bulk", "application/x-ndjson", body.Bytes())
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError:
+		return nil, nil, fmt.Errorf("elastic: unexpected status <%s>", resp.Status)
+	case resp.StatusCode >= http.StatusBadRequest:
+		return nil, nil, &permanentError{fmt.Errorf("elastic: unexpected status <%s>", resp.Status)}
+	}
+
+	var result bulkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, nil, err
+	}
+
+	if !result.Errors {
+		return nil, nil, nil
+	}
+
+	for i, item := range result.Items {
+
+		if i >= len(events) {
+			break
+		}
+
+		for _, status := range item {
+			switch {
+			case status.Status < http.StatusBadRequest:
+			case status.Status == http.StatusTooManyRequests || status.Status >= http.StatusInternalServerError:
+				failed = append(failed, events[i])
+			default:
+				log.Printf("elastic exporter: document <%s> rejected: %s", e.DocumentID(events[i]), status.Error)
+				rejected = append(rejected, events[i])
+			}
+		}
+	}
+
+	return failed, rejected, nil
+}
+
+func (e *ElasticExporter) do(method, path, contentType string, body []byte) (*http.Response, error) {
+
+	req, err := http.NewRequest(method, e.opts.URL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, &permanentError{err}
+	}
+
+	req.Header.Set("Content-Type", contentType)
+
+	switch {
+	case len(e.opts.APIKey) > 0:
+		req.Header.Set("Authorization", "ApiKey "+e.opts.APIKey)
+	case len(e.opts.Username) > 0:
+		req.SetBasicAuth(e.opts.Username, e.opts.Password)
+	}
+
+	return e.client.Do(req)
+}
+
+// eventInfobaseName возвращает имя информационной базы события.
+// Если имя неизвестно, то используется имя каталога информационной базы
+func eventInfobaseName(event eventlog.Event) string {
+
+	if len(event.Infobase) > 0 {
+		return event.Infobase
+	}
+
+	if len(event.InfobaseDir) > 0 {
+		return filepath.Base(event.InfobaseDir)
+	}
+
+	if len(event.JournalFile) == 0 {
+		return "default"
+	}
+
+	dir := filepath.Dir(event.JournalFile)
+
+	// Журнал хранится в каталоге 1Cv8Log информационной базы
+	if strings.EqualFold(filepath.Base(dir), "1Cv8Log") {
+		dir = filepath.Dir(dir)
+	}
+
+	return filepath.Base(dir)
+}
@@ -0,0 +1,257 @@
+package exporter
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/v8platform/eventlog"
+)
+
+// elasticStandIn минимальная замена _bulk API кластера
+type elasticStandIn struct {
+	mu        sync.Mutex
+	docs      map[string]eventlog.Event
+	attempts  map[string]int
+	templates map[string]json.RawMessage
+}
+
+func newElasticStandIn() *elasticStandIn {
+	return &elasticStandIn{
+		docs:      make(map[string]eventlog.Event),
+		attempts:  make(map[string]int),
+		templates: make(map[string]json.RawMessage),
+	}
+}
+
+func (s *elasticStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if strings.HasPrefix(r.URL.Path, "/_index_template/") {
+		var template json.RawMessage
+		_ = json.NewDecoder(r.Body).Decode(&template)
+		s.templates[strings.TrimPrefix(r.URL.Path, "/_index_template/")] = template
+		return
+	}
+
+	type item struct {
+		Status int         `json:"status"`
+		Error  interface{} `json:"error,omitempty"`
+	}
+
+	var (
+		items  []map[string]item
+		errors bool
+	)
+
+	scanner := bufio.NewScanner(r.Body)
+	for scanner.Scan() {
+
+		var action struct {
+			Index struct {
+				Index string `json:"_index"`
+				ID    string `json:"_id"`
+			} `json:"index"`
+		}
+		_ = json.Unmarshal(scanner.Bytes(), &action)
+
+		scanner.Scan()
+		var event eventlog.Event
+		_ = json.Unmarshal(scanner.Bytes(), &event)
+
+		key := action.Index.Index + "/" + action.Index.ID
+		s.attempts[key]++
+
+		status := http.StatusCreated
+		switch {
+		case event.Comment == "reject":
+			status = http.StatusBadRequest
+		case event.Comment == "busy" && s.attempts[key] == 1:
+			status = http.StatusTooManyRequests
+		default:
+			s.docs[key] = event
+		}
+
+		if status >= http.StatusBadRequest {
+			errors = true
+			items = append(items, map[string]item{"index": {Status: status, Error: map[string]string{"type": "error"}}})
+			continue
+		}
+
+		items = append(items, map[string]item{"index": {Status: status}})
+	}
+
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": errors,
+		"items":  items,
+	})
+}
+
+func TestElasticExporter(t *testing.T) {
+
+	standIn := newElasticStandIn()
+	ts := httptest.NewServer(standIn)
+	defer ts.Close()
+
+	deadLetterFile := t.TempDir() + "/dead.jsonl"
+
+	e := NewElasticExporter(ElasticOptions{
+		URL:            ts.URL,
+		BatchSize:      4,
+		MinBackoff:     time.Millisecond,
+		DeadLetterFile: deadLetterFile,
+	})
+
+	events := testEvents(10)
+	for i := range events {
+		events[i].JournalFile = "/srv/Buh/1Cv8Log/20210108100000.lgp"
+	}
+	events[2].Comment = "busy"
+	events[5].Comment = "reject"
+
+	for _, event := range events {
+		e.Push(event)
+	}
+
+	if err := e.Flush(); err == nil {
+		t.Error("Flush() want error for rejected document")
+	}
+
+	// Повторная выгрузка не создает дублей
+	for _, event := range events[:3] {
+		e.Push(event)
+	}
+
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	standIn.mu.Lock()
+	defer standIn.mu.Unlock()
+
+	if len(standIn.docs) != 9 {
+		t.Errorf("indexed documents = %d, want 9", len(standIn.docs))
+	}
+
+	wantKey := "eventlog-buh-2021.01.08/" + events[0].Key()
+	if _, ok := standIn.docs[wantKey]; !ok {
+		t.Errorf("document <%s> not found", wantKey)
+	}
+
+	data, _ := os.ReadFile(deadLetterFile)
+	if !strings.Contains(string(data), `"Comment":"reject"`) || strings.Count(string(data), "\n") != 1 {
+		t.Errorf("dead letter = %s", data)
+	}
+}
+
+func TestElasticExporter_IndexTemplate(t *testing.T) {
+
+	standIn := newElasticStandIn()
+	ts := httptest.NewServer(standIn)
+	defer ts.Close()
+
+	e := NewElasticExporter(ElasticOptions{URL: ts.URL})
+	defer e.Close()
+
+	template := e.IndexTemplate()
+
+	if patterns := template["index_patterns"].([]string); patterns[0] != "eventlog-*-*" {
+		t.Errorf("index_patterns = %v", patterns)
+	}
+
+	properties := template["template"].(map[string]interface{})["mappings"].(map[string]interface{})["properties"].(map[string]interface{})
+
+	want := map[string]string{
+		"Date":              "date",
+		"TransactionNumber": "long",
+		"User":              "keyword",
+		"Comment":           "text",
+		"Data":              "object",
+	}
+
+	for field, typ := range want {
+		if got := properties[field].(map[string]interface{})["type"]; got != typ {
+			t.Errorf("mapping %s = %v, want %v", field, got, typ)
+		}
+	}
+
+	if err := e.PutIndexTemplate("eventlog"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := standIn.templates["eventlog"]; !ok {
+		t.Error("index template not created")
+	}
+}
+
+func TestElasticExporter_IndexName(t *testing.T) {
+
+	date := time.Date(2021, 1, 8, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		index    string
+		infobase string
+		want     string
+	}{
+		{"default", "", "Buh", "eventlog-buh-2021.01.08"},
+		{"spaces", "", "Бухгалтерия Главная", "eventlog-бухгалтерия_главная-2021.01.08"},
+		{"forbidden", "", `a\b/c*d?e"f<g>h|i,j#k:l`, "eventlog-a_b_c_d_e_f_g_h_i_j_k_l-2021.01.08"},
+		{"leading", "{infobase}-{yyyy}", "_-+Buh", "buh-2021"},
+		{"template", "Logs #{infobase}", "zup", "logs__zup"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewElasticExporter(ElasticOptions{Index: tt.index, Infobase: tt.infobase})
+			defer e.Close()
+
+			if got := e.IndexName(eventlog.Event{DateUTC: date}); got != tt.want {
+				t.Errorf("IndexName() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestElasticExporter_DocumentID(t *testing.T) {
+
+	e := NewElasticExporter(ElasticOptions{})
+	defer e.Close()
+
+	date := time.Date(2021, 1, 8, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		a, b eventlog.Event
+		same bool
+	}{
+		{
+			eventlog.Event{JournalFile: "/ib/1Cv8Log/20210108100000.lgp", Offset: 100},
+			eventlog.Event{JournalFile: "/ib/1Cv8Log/20210108110000.lgp", Offset: 100},
+			false,
+		},
+		{
+			eventlog.Event{Date: date, Session: 1, Offset: 100},
+			eventlog.Event{Date: date.Add(time.Hour), Session: 1, Offset: 100},
+			false,
+		},
+		{
+			eventlog.Event{Date: date, Session: 1, Offset: 100},
+			eventlog.Event{Date: date, Session: 1, Offset: 100},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		if same := e.DocumentID(tt.a) == e.DocumentID(tt.b); same != tt.same {
+			t.Errorf("DocumentID(%s) and DocumentID(%s) same = %v, want %v", e.DocumentID(tt.a), e.DocumentID(tt.b), same, tt.same)
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package exporter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/v8platform/eventlog"
+)
+
+type HTTPOptions struct {
+	URL         string
+	Headers     map[string]string
+	Username    string // Пользователь для basic авторизации
+	Password    string
+	BearerToken string
+	Gzip        bool // Сжимать тело запроса
+
+	BatchSize     int           // Количество событий в пачке, по умолчанию 100
+	FlushInterval time.Duration // Период отправки неполной пачки, по умолчанию 1 секунда
+	MaxInFlight   int           // Количество одновременных запросов, по умолчанию 4
+
+	MaxRetries int           // Количество повторных попыток отправки, по умолчанию 5
+	MinBackoff time.Duration // Пауза перед первой повторной попыткой, далее удваивается
+	MaxBackoff time.Duration
+
+	// DeadLetterFile файл для пачек, которые не удалось отправить после всех попыток
+	DeadLetterFile string
+
+	Client *http.Client
+}
+
+var (
+	_ eventlog.ExporterStorage = (*HTTPExporter)(nil)
+	_ eventlog.EventWriter     = (*HTTPExporter)(nil)
+)
+
+// HTTPExporter отправляет события пачками в виде JSON массива POST запросом
+type HTTPExporter struct {
+	opts       HTTPOptions
+	client     *http.Client
+	batcher    *batcher
+	deadLetter *deadLetter
+}
+
+func NewHTTPExporter(opts HTTPOptions) *HTTPExporter {
+
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = defaultMaxRetries
+	}
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = defaultMinBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = defaultMaxBackoff
+	}
+
+	e := &HTTPExporter{
+		opts:       opts,
+		client:     opts.Client,
+		deadLetter: &deadLetter{file: opts.DeadLetterFile},
+	}
+
+	if e.client == nil {
+		e.client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	e.batcher = newBatcher(opts.BatchSize, opts.FlushInterval, opts.MaxInFlight, e.send)
+
+	return e
+}
+
+func (e *HTTPExporter) Push(event eventlog.Event) {
+	e.batcher.Push(event)
+}
+
+// Flush отправляет накопленные события и ожидает завершения всех запросов
+func (e *HTTPExporter) Flush() error {
+	return e.batcher.Flush()
+}
+
+func (e *HTTPExporter) Close() error {
+	return e.batcher.Close()
+}
+
+func (e *HTTPExporter) send(events []eventlog.Event) error {
+
+	body, err := e.encode(events)
+	if err != nil {
+		return err
+	}
+
+	err = retry(e.opts.MaxRetries, e.opts.MinBackoff, e.opts.MaxBackoff, func() error {
+		return e.post(body)
+	})
+
+	if err == nil {
+		return nil
+	}
+
+	log.Printf("http exporter: send %d events: %s", len(events), err)
+
+	if dlErr := e.deadLetter.Write(events); dlErr != nil {
+		log.Printf("http exporter: write dead letter: %s", dlErr)
+	}
+
+	return err
+}
+
+// WriteEvents отправляет пачку событий одним запросом без повторных попыток.
+// События, которые сервер отклонил без возможности повтора, записываются в DeadLetterFile
+func (e *HTTPExporter) WriteEvents(events []eventlog.Event) error {
+
+	body, err := e.encode(events)
+	if err != nil {
+		return err
+	}
+
+	err = e.post(body)
+
+	var permanent *permanentError
+	if errors.As(err, &permanent) {
+		log.Printf("http exporter: send %d events: %s", len(events), err)
+		if dlErr := e.deadLetter.Write(events); dlErr != nil {
+			log.Printf("http exporter: write dead letter: %s", dlErr)
+		}
+		return nil
+	}
+
+	return err
+}
+
+func (e *HTTPExporter) encode(events []eventlog.Event) ([]byte, error) {
+
+	data, err := json.Marshal(events)
+	if err != nil {
+		return nil, err
+	}
+
+	if !e.opts.Gzip {
+		return data, nil
+	}
+
+	buf := &bytes.Buffer{}
+	zw := gzip.NewWriter(buf)
+
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (e *HTTPExporter) post(body []byte) error {
+
+	req, err := http.NewRequest(http.MethodPost, e.opts.URL, bytes.NewReader(body))
+	if err != nil {
+		return &permanentError{err}
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if e.opts.Gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	switch {
+	case len(e.opts.BearerToken) > 0:
+		req.Header.Set("Authorization", "Bearer "+e.opts.BearerToken)
+	case len(e.opts.Username) > 0:
+		req.SetBasicAuth(e.opts.Username, e.opts.Password)
+	}
+
+	for key, value := range e.opts.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode < http.StatusBadRequest:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode == http.StatusRequestTimeout ||
+		resp.StatusCode >= http.StatusInternalServerError:
+		return fmt.Errorf("unexpected status <%s>", resp.Status)
+	default:
+		// Ошибки запроса не исправятся повторной отправкой
+		return &permanentError{fmt.Errorf("unexpected status <%s>", resp.Status)}
+	}
+}
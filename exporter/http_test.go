@@ -0,0 +1,215 @@
+package exporter
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/v8platform/eventlog"
+)
+
+func testEvents(count int) []eventlog.Event {
+
+	events := make([]eventlog.Event, 0, count)
+	for i := 0; i < count; i++ {
+		events = append(events, eventlog.Event{
+			Date:        time.Date(2021, 1, 8, 10, 24, 40+i, 0, time.UTC),
+			Event:       "_$Data$_.New",
+			Severity:    eventlog.SeverityInfo,
+			User:        "Администратор",
+			Session:     int64(i % 3),
+			Offset:      int64(100 * (i + 1)),
+			JournalUUID: "5e9a7aa8-4efa-11e9-a98f-005056aea130",
+		})
+	}
+
+	return events
+}
+
+func TestHTTPExporter(t *testing.T) {
+
+	var (
+		mu       sync.Mutex
+		received []eventlog.Event
+		requests int32
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		// Первый запрос завершается ошибкой для проверки повторной отправки
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if r.Header.Get("X-Source") != "eventlog" || r.Header.Get("Content-Encoding") != "gzip" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var events []eventlog.Event
+		if err := json.NewDecoder(zr).Decode(&events); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		received = append(received, events...)
+		mu.Unlock()
+	}))
+	defer ts.Close()
+
+	e := NewHTTPExporter(HTTPOptions{
+		URL:         ts.URL,
+		Headers:     map[string]string{"X-Source": "eventlog"},
+		Username:    "user",
+		Password:    "pass",
+		Gzip:        true,
+		BatchSize:   10,
+		MaxInFlight: 1,
+		MinBackoff:  time.Millisecond,
+	})
+
+	for _, event := range testEvents(25) {
+		e.Push(event)
+	}
+
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(received) != 25 {
+		t.Errorf("received %d events, want 25", len(received))
+	}
+}
+
+func TestHTTPExporter_DeadLetter(t *testing.T) {
+
+	var requests int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer ts.Close()
+
+	deadLetterFile := filepath.Join(t.TempDir(), "dead.jsonl")
+
+	e := NewHTTPExporter(HTTPOptions{
+		URL:            ts.URL,
+		BatchSize:      2,
+		MinBackoff:     time.Millisecond,
+		DeadLetterFile: deadLetterFile,
+	})
+
+	for _, event := range testEvents(3) {
+		e.Push(event)
+	}
+
+	if err := e.Flush(); err == nil {
+		t.Error("Flush() want error")
+	}
+
+	// Ошибка запроса не повторяется
+	if requests := atomic.LoadInt32(&requests); requests != 2 {
+		t.Errorf("requests = %d, want 2", requests)
+	}
+
+	data, err := os.ReadFile(deadLetterFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Errorf("dead letter batches = %d, want 2", lines)
+	}
+
+	_ = e.Close()
+}
+
+func TestHTTPExporter_WriteEvents(t *testing.T) {
+
+	var status int32 = http.StatusServiceUnavailable
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(atomic.LoadInt32(&status)))
+	}))
+	defer ts.Close()
+
+	deadLetterFile := filepath.Join(t.TempDir(), "dead.jsonl")
+
+	e := NewHTTPExporter(HTTPOptions{
+		URL:            ts.URL,
+		DeadLetterFile: deadLetterFile,
+	})
+	defer e.Close()
+
+	// Временная ошибка возвращается для повторной записи
+	if err := e.WriteEvents(testEvents(2)); err == nil {
+		t.Error("WriteEvents() want error for unavailable server")
+	}
+
+	atomic.StoreInt32(&status, http.StatusOK)
+	if err := e.WriteEvents(testEvents(2)); err != nil {
+		t.Errorf("WriteEvents() error = %v", err)
+	}
+
+	// Отклоненные события уходят в dead letter и не задерживают запись
+	atomic.StoreInt32(&status, http.StatusBadRequest)
+	if err := e.WriteEvents(testEvents(2)); err != nil {
+		t.Errorf("WriteEvents() error = %v for rejected events", err)
+	}
+	if _, err := os.Stat(deadLetterFile); err != nil {
+		t.Errorf("dead letter not written: %s", err)
+	}
+}
+
+func TestHTTPExporter_PushAfterClose(t *testing.T) {
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	e := NewHTTPExporter(HTTPOptions{URL: ts.URL})
+
+	e.Push(testEvents(1)[0])
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Событие не отправлено, позиция не должна быть зафиксирована
+	e.Push(testEvents(1)[0])
+
+	if err := e.Flush(); err == nil {
+		t.Error("Flush() want error for event pushed after close")
+	}
+	if err := e.Flush(); err != nil {
+		t.Errorf("Flush() error = %v without new events", err)
+	}
+
+	e.Push(testEvents(1)[0])
+	if err := e.Close(); err == nil {
+		t.Error("Close() want error for event pushed after close")
+	}
+}
@@ -0,0 +1,254 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/v8platform/eventlog"
+)
+
+// Message сообщение для брокера
+type Message struct {
+	Topic   string
+	Key     []byte
+	Value   []byte
+	Headers map[string]string
+}
+
+// Producer отправляет сообщения в брокер (Kafka или совместимый).
+// Produce возвращает управление после подтверждения записи всех сообщений брокером
+type Producer interface {
+	Produce(ctx context.Context, messages []Message) error
+}
+
+// MessageEncoder кодирует событие в тело сообщения
+type MessageEncoder interface {
+	Encode(event eventlog.Event) ([]byte, error)
+	ContentType() string
+}
+
+type JSONEncoder struct{}
+
+func (JSONEncoder) Encode(event eventlog.Event) ([]byte, error) {
+	return json.Marshal(event)
+}
+
+func (JSONEncoder) ContentType() string {
+	return "application/json"
+}
+
+// KeyBySession ключ сообщения по информационной базе и сеансу.
+// События одного сеанса попадают в одну партицию и сохраняют порядок
+func KeyBySession(event eventlog.Event) string {
+	return eventInfobaseName(event) + "/" + strconv.FormatInt(event.Session, 10)
+}
+
+// KeyByInfobase ключ сообщения по информационной базе
+func KeyByInfobase(event eventlog.Event) string {
+	return eventInfobaseName(event)
+}
+
+type KafkaOptions struct {
+	Producer Producer
+	Topic    string
+	Encoder  MessageEncoder                    // По умолчанию JSONEncoder
+	Key      func(event eventlog.Event) string // По умолчанию KeyBySession
+
+	// Journal хранилище позиций чтения. После подтверждения пачки брокером
+	// для файлов журнала фиксируется позиция за последним отправленным событием
+	Journal eventlog.JournalStorage
+
+	BatchSize     int
+	FlushInterval time.Duration
+	Timeout       time.Duration // Таймаут подтверждения пачки брокером, по умолчанию 30 секунд
+
+	MaxRetries int
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// DeadLetterFile файл для событий, которые не удалось закодировать
+	DeadLetterFile string
+}
+
+var _ eventlog.ExporterStorage = (*KafkaExporter)(nil)
+
+// KafkaExporter отправляет события сообщениями в брокер пачками.
+//
+// События, которые не удалось закодировать, записываются в DeadLetterFile и считаются обработанными.
+// Если записать их не удалось, то позиция файла журнала не фиксируется дальше такого события,
+// а Flush возвращает ошибку, поэтому менеджер прочитает событие повторно
+type KafkaExporter struct {
+	opts       KafkaOptions
+	batcher    *batcher
+	deadLetter *deadLetter
+
+	mu   sync.Mutex
+	held map[string]int64 // Позиции событий файлов, которые не удалось ни закодировать, ни записать в DeadLetterFile
+}
+
+func NewKafkaExporter(opts KafkaOptions) (*KafkaExporter, error) {
+
+	if opts.Producer == nil {
+		return nil, errors.New("kafka exporter: producer is required")
+	}
+	if len(opts.Topic) == 0 {
+		return nil, errors.New("kafka exporter: topic is required")
+	}
+	if opts.Encoder == nil {
+		opts.Encoder = JSONEncoder{}
+	}
+	if opts.Key == nil {
+		opts.Key = KeyBySession
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 30 * time.Second
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = defaultMaxRetries
+	}
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = defaultMinBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = defaultMaxBackoff
+	}
+
+	e := &KafkaExporter{
+		opts:       opts,
+		deadLetter: &deadLetter{file: opts.DeadLetterFile},
+		held:       make(map[string]int64),
+	}
+
+	// Пачки отправляются последовательно, чтобы позиции фиксировались по порядку
+	e.batcher = newBatcher(opts.BatchSize, opts.FlushInterval, 1, e.send)
+
+	return e, nil
+}
+
+func (e *KafkaExporter) Push(event eventlog.Event) {
+	e.batcher.Push(event)
+}
+
+func (e *KafkaExporter) Flush() error {
+	return e.batcher.Flush()
+}
+
+func (e *KafkaExporter) Close() error {
+	return e.batcher.Close()
+}
+
+func (e *KafkaExporter) send(events []eventlog.Event) error {
+
+	var (
+		messages = make([]Message, 0, len(events))
+		failed   []eventlog.Event
+	)
+
+	for _, event := range events {
+
+		value, err := e.opts.Encoder.Encode(event)
+		if err != nil {
+			log.Printf("kafka exporter: encode event <%s>: %s", event.Key(), err)
+			failed = append(failed, event)
+			continue
+		}
+
+		messages = append(messages, Message{
+			Topic: e.opts.Topic,
+			Key:   []byte(e.opts.Key(event)),
+			Value: value,
+			Headers: map[string]string{
+				"content-type": e.opts.Encoder.ContentType(),
+				"event-key":    event.Key(),
+			},
+		})
+	}
+
+	if len(messages) > 0 {
+		err := retry(e.opts.MaxRetries, e.opts.MinBackoff, e.opts.MaxBackoff, func() error {
+			ctx, cancel := context.WithTimeout(context.Background(), e.opts.Timeout)
+			defer cancel()
+			return e.opts.Producer.Produce(ctx, messages)
+		})
+
+		if err != nil {
+			err = fmt.Errorf("kafka exporter: produce %d messages: %w", len(messages), err)
+			log.Print(err)
+			return err
+		}
+	}
+
+	// Событие, записанное в DeadLetterFile, считается обработанным
+	if len(failed) > 0 {
+		if err := e.deadLetter.Write(failed); err != nil {
+			err = fmt.Errorf("kafka exporter: %d events not encoded, write dead letter: %w", len(failed), err)
+			log.Print(err)
+			e.commit(events, failed)
+			return err
+		}
+	}
+
+	e.commit(events, nil)
+
+	return nil
+}
+
+// commit фиксирует позиции чтения файлов журнала после подтверждения пачки.
+// Позиция не фиксируется дальше событий held, которые не удалось обработать
+func (e *KafkaExporter) commit(events, held []eventlog.Event) {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	unhandled := make(map[string]struct{}, len(held))
+	for _, event := range held {
+		unhandled[event.Key()] = struct{}{}
+		if offset, ok := e.held[event.JournalFile]; !ok || event.Offset < offset {
+			e.held[event.JournalFile] = event.Offset
+		}
+	}
+
+	// Событие обработано при повторном чтении файла
+	for _, event := range events {
+		if _, ok := unhandled[event.Key()]; ok {
+			continue
+		}
+		if offset, ok := e.held[event.JournalFile]; ok && offset == event.Offset {
+			delete(e.held, event.JournalFile)
+		}
+	}
+
+	if e.opts.Journal == nil {
+		return
+	}
+
+	offsets := make(map[string]int64)
+
+	for _, event := range events {
+
+		if len(event.JournalFile) == 0 {
+			continue
+		}
+
+		next := event.Offset + event.Size
+		if offset, ok := e.held[event.JournalFile]; ok && next > offset {
+			next = offset
+		}
+
+		if next > offsets[event.JournalFile] {
+			offsets[event.JournalFile] = next
+		}
+	}
+
+	for file, offset := range offsets {
+		if offset > e.opts.Journal.GetOffset(file) {
+			e.opts.Journal.SetOffset(file, offset)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/v8platform/eventlog"
+)
+
+// fakeBroker брокер в памяти с разбиением сообщений по партициям по ключу
+type fakeBroker struct {
+	mu         sync.Mutex
+	partitions [][]Message
+	fail       int // Количество запросов, завершающихся ошибкой
+}
+
+func newFakeBroker(partitions int) *fakeBroker {
+	return &fakeBroker{
+		partitions: make([][]Message, partitions),
+	}
+}
+
+func (b *fakeBroker) Produce(_ context.Context, messages []Message) error {
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.fail > 0 {
+		b.fail--
+		return errors.New("broker not available")
+	}
+
+	for _, message := range messages {
+		h := fnv.New32a()
+		_, _ = h.Write(message.Key)
+		partition := int(h.Sum32()) % len(b.partitions)
+		b.partitions[partition] = append(b.partitions[partition], message)
+	}
+
+	return nil
+}
+
+func TestKafkaExporter(t *testing.T) {
+
+	broker := newFakeBroker(3)
+	broker.fail = 1
+
+	journal := eventlog.NewInMemoryJournal()
+
+	e, err := NewKafkaExporter(KafkaOptions{
+		Producer:   broker,
+		Topic:      "eventlog",
+		Journal:    journal,
+		BatchSize:  4,
+		MinBackoff: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := testEvents(9)
+	for i := range events {
+		events[i].JournalFile = "/srv/Buh/1Cv8Log/20210108100000.lgp"
+		events[i].Size = 50
+	}
+
+	for _, event := range events {
+		e.Push(event)
+	}
+
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	broker.mu.Lock()
+	defer broker.mu.Unlock()
+
+	var total int
+	keyPartition := make(map[string]int)
+
+	for i, partition := range broker.partitions {
+
+		var last int64
+
+		for _, message := range partition {
+
+			if p, ok := keyPartition[string(message.Key)]; ok && p != i {
+				t.Errorf("key <%s> in several partitions", message.Key)
+			}
+			keyPartition[string(message.Key)] = i
+
+			var event eventlog.Event
+			if err := json.Unmarshal(message.Value, &event); err != nil {
+				t.Fatal(err)
+			}
+
+			if event.Offset < last {
+				t.Errorf("partition order broken: %d after %d", event.Offset, last)
+			}
+			last = event.Offset
+			total++
+		}
+	}
+
+	if total != 9 {
+		t.Errorf("messages = %d, want 9", total)
+	}
+
+	want := events[8].Offset + events[8].Size
+	if got := journal.GetOffset(events[0].JournalFile); got != want {
+		t.Errorf("committed offset = %d, want %d", got, want)
+	}
+
+	if key := KeyBySession(events[1]); key != "Buh/1" {
+		t.Errorf("KeyBySession() = %s", key)
+	}
+}
+
+func TestKafkaExporter_NoCommitOnFailure(t *testing.T) {
+
+	broker := newFakeBroker(1)
+	broker.fail = 100
+
+	journal := eventlog.NewInMemoryJournal()
+
+	e, _ := NewKafkaExporter(KafkaOptions{
+		Producer:   broker,
+		Topic:      "eventlog",
+		Journal:    journal,
+		MaxRetries: 1,
+		MinBackoff: time.Millisecond,
+	})
+
+	event := testEvents(1)[0]
+	event.JournalFile = "20210108100000.lgp"
+	e.Push(event)
+
+	if err := e.Close(); err == nil {
+		t.Error("Close() want error")
+	}
+
+	if got := journal.GetOffset(event.JournalFile); got != 0 {
+		t.Errorf("committed offset = %d, want 0", got)
+	}
+}
+
+// failEncoder не кодирует событие с заданным смещением
+type failEncoder struct {
+	JSONEncoder
+	offset int64
+}
+
+func (e failEncoder) Encode(event eventlog.Event) ([]byte, error) {
+	if event.Offset == e.offset {
+		return nil, errors.New("encode failed")
+	}
+	return e.JSONEncoder.Encode(event)
+}
+
+func TestKafkaExporter_EncodeFailure(t *testing.T) {
+
+	newEvents := func() []eventlog.Event {
+		events := testEvents(9)
+		for i := range events {
+			events[i].JournalFile = "20210108100000.lgp"
+			events[i].Size = 50
+		}
+		return events
+	}
+
+	t.Run("dead letter", func(t *testing.T) {
+
+		broker := newFakeBroker(1)
+		journal := eventlog.NewInMemoryJournal()
+		deadLetterFile := filepath.Join(t.TempDir(), "dead.jsonl")
+		events := newEvents()
+
+		e, _ := NewKafkaExporter(KafkaOptions{
+			Producer:       broker,
+			Topic:          "eventlog",
+			Encoder:        failEncoder{offset: events[2].Offset},
+			Journal:        journal,
+			BatchSize:      4,
+			DeadLetterFile: deadLetterFile,
+		})
+
+		for _, event := range events {
+			e.Push(event)
+		}
+
+		if err := e.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		if n := len(broker.partitions[0]); n != 8 {
+			t.Errorf("messages = %d, want 8", n)
+		}
+
+		// Событие записано в DeadLetterFile, позиция фиксируется за ним
+		last := events[len(events)-1]
+		if got := journal.GetOffset(last.JournalFile); got != last.Offset+last.Size {
+			t.Errorf("committed offset = %d, want %d", got, last.Offset+last.Size)
+		}
+
+		data, err := os.ReadFile(deadLetterFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var dead []eventlog.Event
+		if err := json.Unmarshal(data, &dead); err != nil || len(dead) != 1 || dead[0].Offset != events[2].Offset {
+			t.Errorf("dead letter = %s, %v", data, err)
+		}
+	})
+
+	t.Run("dead letter failed", func(t *testing.T) {
+
+		broker := newFakeBroker(1)
+		journal := eventlog.NewInMemoryJournal()
+		deadLetterFile := filepath.Join(t.TempDir(), "missing", "dead.jsonl")
+		events := newEvents()
+
+		e, _ := NewKafkaExporter(KafkaOptions{
+			Producer:       broker,
+			Topic:          "eventlog",
+			Encoder:        failEncoder{offset: events[2].Offset},
+			Journal:        journal,
+			BatchSize:      4,
+			DeadLetterFile: deadLetterFile,
+		})
+
+		for _, event := range events {
+			e.Push(event)
+		}
+
+		if err := e.Flush(); err == nil {
+			t.Error("Flush() want error")
+		}
+
+		// Позиция не фиксируется дальше необработанного события и в следующих пачках
+		if got := journal.GetOffset(events[0].JournalFile); got != events[2].Offset {
+			t.Errorf("committed offset = %d, want %d", got, events[2].Offset)
+		}
+
+		// Файл прочитан повторно, событие записано в DeadLetterFile
+		if err := os.MkdirAll(filepath.Dir(deadLetterFile), 0755); err != nil {
+			t.Fatal(err)
+		}
+		for _, event := range events[2:] {
+			e.Push(event)
+		}
+
+		if err := e.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		last := events[len(events)-1]
+		if got := journal.GetOffset(last.JournalFile); got != last.Offset+last.Size {
+			t.Errorf("committed offset after retry = %d, want %d", got, last.Offset+last.Size)
+		}
+	})
+}
@@ -0,0 +1,387 @@
+package exporter
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/v8platform/eventlog"
+)
+
+type SQLDialect int
+
+const (
+	DialectPostgres SQLDialect = iota
+	DialectSQLite
+)
+
+func (d SQLDialect) primaryKey() string {
+	if d == DialectSQLite {
+		return "INTEGER PRIMARY KEY AUTOINCREMENT"
+	}
+	return "BIGSERIAL PRIMARY KEY"
+}
+
+func (d SQLDialect) timestamp() string {
+	if d == DialectSQLite {
+		return "TIMESTAMP"
+	}
+	return "TIMESTAMPTZ"
+}
+
+type SQLOptions struct {
+	DB          *sql.DB
+	Dialect     SQLDialect
+	TablePrefix string // Префикс имен таблиц, по умолчанию eventlog_
+
+	BatchSize     int
+	FlushInterval time.Duration
+
+	MaxRetries int
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// DeadLetterFile файл для событий, не записанных после всех попыток
+	DeadLetterFile string
+}
+
+var (
+	_ eventlog.ExporterStorage = (*SQLExporter)(nil)
+	_ eventlog.EventWriter     = (*SQLExporter)(nil)
+)
+
+// SQLExporter записывает события в базу данных через database/sql.
+//
+// Пользователи, компьютеры, приложения и метаданные хранятся в отдельных таблицах-справочниках.
+// Событие однозначно определяется журналом, файлом и смещением в нем,
+// поэтому повторная обработка после сбоя не создает дублей
+type SQLExporter struct {
+	db         *sql.DB
+	opts       SQLOptions
+	dialect    SQLDialect
+	prefix     string
+	batcher    *batcher
+	deadLetter *deadLetter
+
+	mu    sync.Mutex
+	cache map[string]int64 // Идентификаторы элементов справочников
+}
+
+// NewSQLExporter создает экспортер и при необходимости создает таблицы
+func NewSQLExporter(opts SQLOptions) (*SQLExporter, error) {
+
+	if opts.DB == nil {
+		return nil, errors.New("sql exporter: db is required")
+	}
+
+	prefix := opts.TablePrefix
+	if len(prefix) == 0 {
+		prefix = "eventlog_"
+	}
+
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = defaultMaxRetries
+	}
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = defaultMinBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = defaultMaxBackoff
+	}
+
+	e := &SQLExporter{
+		db:         opts.DB,
+		opts:       opts,
+		dialect:    opts.Dialect,
+		prefix:     prefix,
+		deadLetter: &deadLetter{file: opts.DeadLetterFile},
+		cache:      make(map[string]int64),
+	}
+
+	if err := e.CreateSchema(context.Background()); err != nil {
+		return nil, err
+	}
+
+	// Запись в одну таблицу выполняется последовательно
+	e.batcher = newBatcher(opts.BatchSize, opts.FlushInterval, 1, e.send)
+
+	return e, nil
+}
+
+func (e *SQLExporter) table(name string) string {
+	return e.prefix + name
+}
+
+// CreateSchema создает таблицы событий и справочников, если их нет
+func (e *SQLExporter) CreateSchema(ctx context.Context) error {
+
+	pk := e.dialect.primaryKey()
+	ts := e.dialect.timestamp()
+
+	statements := []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id %s,
+	name TEXT NOT NULL,
+	uuid TEXT NOT NULL,
+	UNIQUE (name, uuid)
+)`, e.table("users"), pk),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id %s,
+	name TEXT NOT NULL UNIQUE
+)`, e.table("computers"), pk),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id %s,
+	name TEXT NOT NULL UNIQUE
+)`, e.table("applications"), pk),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id %s,
+	name TEXT NOT NULL,
+	uuid TEXT NOT NULL,
+	UNIQUE (name, uuid)
+)`, e.table("metadata"), pk),
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]s (
+	id %[2]s,
+	journal_uuid TEXT NOT NULL,
+	journal_file TEXT NOT NULL,
+	"offset" BIGINT NOT NULL,
+	size BIGINT NOT NULL,
+	date %[3]s NOT NULL,
+	transaction_status TEXT NOT NULL,
+	transaction_date %[3]s NULL,
+	transaction_number BIGINT NOT NULL,
+	user_id BIGINT NULL REFERENCES %[4]s (id),
+	computer_id BIGINT NULL REFERENCES %[5]s (id),
+	application_id BIGINT NULL REFERENCES %[6]s (id),
+	connection BIGINT NOT NULL,
+	event TEXT NOT NULL,
+	severity TEXT NOT NULL,
+	comment TEXT NOT NULL,
+	metadata_id BIGINT NULL REFERENCES %[7]s (id),
+	data TEXT NULL,
+	data_presentation TEXT NOT NULL,
+	server TEXT NOT NULL,
+	main_port TEXT NOT NULL,
+	add_port TEXT NOT NULL,
+	session BIGINT NOT NULL,
+	UNIQUE (journal_uuid, journal_file, "offset")
+)`, e.table("events"), pk, ts, e.table("users"), e.table("computers"), e.table("applications"), e.table("metadata")),
+	}
+
+	for _, statement := range statements {
+		if _, err := e.db.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *SQLExporter) Push(event eventlog.Event) {
+	e.batcher.Push(event)
+}
+
+func (e *SQLExporter) Flush() error {
+	return e.batcher.Flush()
+}
+
+// Close записывает оставшиеся события. Соединение с базой данных не закрывается
+func (e *SQLExporter) Close() error {
+	return e.batcher.Close()
+}
+
+func (e *SQLExporter) send(events []eventlog.Event) error {
+
+	err := retry(e.opts.MaxRetries, e.opts.MinBackoff, e.opts.MaxBackoff, func() error {
+		return e.insert(context.Background(), events)
+	})
+
+	if err == nil {
+		return nil
+	}
+
+	err = fmt.Errorf("sql exporter: %d events not inserted: %w", len(events), err)
+	log.Print(err)
+
+	if dlErr := e.deadLetter.Write(events); dlErr != nil {
+		log.Printf("sql exporter: write dead letter: %s", dlErr)
+	}
+
+	return err
+}
+
+// WriteEvents записывает пачку событий в одной транзакции
+func (e *SQLExporter) WriteEvents(events []eventlog.Event) error {
+	return e.insert(context.Background(), events)
+}
+
+func (e *SQLExporter) insert(ctx context.Context, events []eventlog.Event) error {
+
+	tx, err := e.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	// Идентификаторы справочников, полученные в отмененной транзакции, недействительны
+	cached := make(map[string]int64)
+
+	err = e.insertEvents(ctx, tx, events, cached)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	for key, id := range cached {
+		e.cache[key] = id
+	}
+	e.mu.Unlock()
+
+	return nil
+}
+
+func (e *SQLExporter) insertEvents(ctx context.Context, tx *sql.Tx, events []eventlog.Event, cached map[string]int64) error {
+
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf(`INSERT INTO %s (
+	journal_uuid, journal_file, "offset", size, date,
+	transaction_status, transaction_date, transaction_number,
+	user_id, computer_id, application_id, connection,
+	event, severity, comment, metadata_id, data, data_presentation,
+	server, main_port, add_port, session
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
+ON CONFLICT (journal_uuid, journal_file, "offset") DO NOTHING`, e.table("events")))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, event := range events {
+
+		userID, err := e.dictionaryID(ctx, tx, cached, "users", event.User, event.UserUuid)
+		if err != nil {
+			return err
+		}
+		computerID, err := e.dictionaryID(ctx, tx, cached, "computers", event.Computer)
+		if err != nil {
+			return err
+		}
+		applicationID, err := e.dictionaryID(ctx, tx, cached, "applications", string(event.Application))
+		if err != nil {
+			return err
+		}
+		metadataID, err := e.dictionaryID(ctx, tx, cached, "metadata", event.Metadata, event.MetadataUuid)
+		if err != nil {
+			return err
+		}
+
+		data, err := eventData(event.Data)
+		if err != nil {
+			// Повторная попытка не исправит данные события
+			return &permanentError{err}
+		}
+
+		var transactionDate sql.NullTime
+		if !event.TransactionDate.IsZero() {
+			transactionDate = sql.NullTime{Time: event.TransactionDate, Valid: true}
+		}
+
+		_, err = stmt.ExecContext(ctx,
+			event.JournalUUID, event.JournalFile, event.Offset, event.Size, event.Date,
+			string(event.TransactionStatus), transactionDate, event.TransactionNumber,
+			userID, computerID, applicationID, event.Connection,
+			string(event.Event), string(event.Severity), event.Comment, metadataID, data, event.DataPresentation,
+			event.Server, event.MainPort, event.AddPort, event.Session,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dictionaryID возвращает идентификатор элемента справочника, при необходимости добавляя его.
+// Для справочников с uuid передается имя и uuid
+func (e *SQLExporter) dictionaryID(ctx context.Context, tx *sql.Tx, cached map[string]int64, table string, values ...string) (sql.NullInt64, error) {
+
+	empty := true
+	for _, value := range values {
+		if len(value) > 0 {
+			empty = false
+		}
+	}
+
+	if empty {
+		return sql.NullInt64{}, nil
+	}
+
+	key := table + "\x00" + strings.Join(values, "\x00")
+
+	if id, ok := cached[key]; ok {
+		return sql.NullInt64{Int64: id, Valid: true}, nil
+	}
+
+	e.mu.Lock()
+	id, ok := e.cache[key]
+	e.mu.Unlock()
+
+	if ok {
+		return sql.NullInt64{Int64: id, Valid: true}, nil
+	}
+
+	columns := "name"
+	where := "name = $1"
+	placeholders := "$1"
+	if len(values) > 1 {
+		columns = "name, uuid"
+		where = "name = $1 AND uuid = $2"
+		placeholders = "$1, $2"
+	}
+
+	args := make([]interface{}, 0, len(values))
+	for _, value := range values {
+		args = append(args, value)
+	}
+
+	_, err := tx.ExecContext(ctx, fmt.Sprintf(`INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) DO NOTHING`,
+		e.table(table), columns, placeholders, columns), args...)
+	if err != nil {
+		return sql.NullInt64{}, err
+	}
+
+	err = tx.QueryRowContext(ctx, fmt.Sprintf(`SELECT id FROM %s WHERE %s`, e.table(table), where), args...).Scan(&id)
+	if err != nil {
+		return sql.NullInt64{}, err
+	}
+
+	cached[key] = id
+
+	return sql.NullInt64{Int64: id, Valid: true}, nil
+}
+
+func eventData(data interface{}) (sql.NullString, error) {
+
+	if data == nil {
+		return sql.NullString{}, nil
+	}
+
+	if s, ok := data.(string); ok && len(s) == 0 {
+		return sql.NullString{}, nil
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
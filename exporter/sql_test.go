@@ -0,0 +1,134 @@
+package exporter
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestSQLExporter(t *testing.T) {
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "eventlog.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	e, err := NewSQLExporter(SQLOptions{
+		DB:        db,
+		Dialect:   DialectSQLite,
+		BatchSize: 4,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := testEvents(10)
+	for i := range events {
+		events[i].JournalFile = "20210108100000.lgp"
+		events[i].Computer = "pc-1"
+		events[i].Data = map[string]interface{}{"Имя": "Администратор"}
+	}
+
+	for _, event := range events {
+		e.Push(event)
+	}
+
+	if err := e.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Повторная обработка после сбоя
+	for _, event := range events[5:] {
+		e.Push(event)
+	}
+
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Новый экспортер использует существующие таблицы
+	if _, err := NewSQLExporter(SQLOptions{DB: db, Dialect: DialectSQLite}); err != nil {
+		t.Fatal(err)
+	}
+
+	var count int
+	if err := db.QueryRow(`SELECT count(*) FROM eventlog_events`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 10 {
+		t.Errorf("events = %d, want 10", count)
+	}
+
+	if err := db.QueryRow(`SELECT count(*) FROM eventlog_users`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("users = %d, want 1", count)
+	}
+
+	var user, computer, data string
+	err = db.QueryRow(`SELECT u.name, c.name, e.data
+FROM eventlog_events e
+	JOIN eventlog_users u ON u.id = e.user_id
+	JOIN eventlog_computers c ON c.id = e.computer_id
+WHERE e."offset" = $1`, events[3].Offset).Scan(&user, &computer, &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if user != "Администратор" || computer != "pc-1" || data != `{"Имя":"Администратор"}` {
+		t.Errorf("event = %s, %s, %s", user, computer, data)
+	}
+}
+
+func TestSQLExporter_DeadLetter(t *testing.T) {
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "eventlog.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	deadLetterFile := filepath.Join(t.TempDir(), "dead.jsonl")
+
+	e, err := NewSQLExporter(SQLOptions{
+		DB:             db,
+		Dialect:        DialectSQLite,
+		BatchSize:      2,
+		MaxRetries:     1,
+		MinBackoff:     time.Millisecond,
+		DeadLetterFile: deadLetterFile,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec(`DROP TABLE eventlog_events`); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, event := range testEvents(3) {
+		e.Push(event)
+	}
+
+	if err := e.Flush(); err == nil {
+		t.Error("Flush() want error")
+	}
+
+	data, err := os.ReadFile(deadLetterFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Errorf("dead letter batches = %d, want 2", lines)
+	}
+
+	_ = e.Close()
+}
@@ -0,0 +1,356 @@
+package exporter
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/v8platform/eventlog"
+)
+
+const (
+	defaultSyslogAppName    = "1cv8-eventlog"
+	defaultSyslogEnterprise = "32473"
+	defaultSyslogFacility   = 16 // local0
+
+	syslogTimestampLayout = "2006-01-02T15:04:05.000000Z07:00"
+)
+
+// Уровни важности syslog
+const (
+	syslogError   = 3
+	syslogWarning = 4
+	syslogNotice  = 5
+	syslogInfo    = 6
+)
+
+type SyslogOptions struct {
+	Network   string // udp, tcp или tls
+	Address   string // Адрес сервера, например siem.local:514
+	TLSConfig *tls.Config
+
+	Hostname string // Имя узла в сообщении, по умолчанию имя текущего компьютера
+	AppName  string // Имя приложения в сообщении, по умолчанию 1cv8-eventlog
+	Facility int    // Источник сообщений, по умолчанию 16 (local0)
+
+	// Enterprise номер предприятия для идентификаторов структурированных данных (user@32473).
+	// По умолчанию используется номер, зарезервированный для примеров
+	Enterprise string
+
+	DialTimeout  time.Duration // По умолчанию 10 секунд
+	WriteTimeout time.Duration // По умолчанию 10 секунд
+
+	BatchSize     int
+	FlushInterval time.Duration
+
+	MaxRetries int
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// DeadLetterFile файл для событий, не отправленных после всех попыток
+	DeadLetterFile string
+}
+
+var (
+	_ eventlog.ExporterStorage = (*SyslogExporter)(nil)
+	_ eventlog.EventWriter     = (*SyslogExporter)(nil)
+)
+
+// SyslogExporter отправляет события сообщениями syslog в формате RFC 5424.
+//
+// По TCP и TLS сообщения разделяются указанием длины (RFC 6587, RFC 5425),
+// по UDP каждое сообщение отправляется отдельной датаграммой.
+// При ошибке записи соединение устанавливается заново
+type SyslogExporter struct {
+	opts       SyslogOptions
+	batcher    *batcher
+	deadLetter *deadLetter
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+func NewSyslogExporter(opts SyslogOptions) (*SyslogExporter, error) {
+
+	switch opts.Network {
+	case "":
+		opts.Network = "udp"
+	case "udp", "tcp", "tls":
+	default:
+		return nil, fmt.Errorf("syslog exporter: unsupported network <%s>", opts.Network)
+	}
+
+	if len(opts.Address) == 0 {
+		return nil, errors.New("syslog exporter: address is required")
+	}
+	if len(opts.Hostname) == 0 {
+		opts.Hostname, _ = os.Hostname()
+	}
+	if len(opts.AppName) == 0 {
+		opts.AppName = defaultSyslogAppName
+	}
+	if opts.Facility == 0 {
+		opts.Facility = defaultSyslogFacility
+	}
+	if opts.Facility < 0 || opts.Facility > 23 {
+		return nil, fmt.Errorf("syslog exporter: invalid facility <%d>", opts.Facility)
+	}
+	if len(opts.Enterprise) == 0 {
+		opts.Enterprise = defaultSyslogEnterprise
+	}
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = 10 * time.Second
+	}
+	if opts.WriteTimeout <= 0 {
+		opts.WriteTimeout = 10 * time.Second
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = defaultMaxRetries
+	}
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = defaultMinBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = defaultMaxBackoff
+	}
+
+	e := &SyslogExporter{
+		opts:       opts,
+		deadLetter: &deadLetter{file: opts.DeadLetterFile},
+	}
+
+	// Сообщения отправляются по одному соединению в порядке событий
+	e.batcher = newBatcher(opts.BatchSize, opts.FlushInterval, 1, e.send)
+
+	return e, nil
+}
+
+func (e *SyslogExporter) Push(event eventlog.Event) {
+	e.batcher.Push(event)
+}
+
+func (e *SyslogExporter) Flush() error {
+	return e.batcher.Flush()
+}
+
+// Close отправляет оставшиеся события и закрывает соединение
+func (e *SyslogExporter) Close() error {
+
+	err := e.batcher.Close()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.conn != nil {
+		if closeErr := e.conn.Close(); err == nil {
+			err = closeErr
+		}
+		e.conn = nil
+	}
+
+	return err
+}
+
+// Format возвращает сообщение syslog для события
+func (e *SyslogExporter) Format(event eventlog.Event) []byte {
+
+	date := event.DateUTC
+	if date.IsZero() {
+		date = event.Date.UTC()
+	}
+
+	timestamp := "-"
+	if !date.IsZero() {
+		timestamp = date.Format(syslogTimestampLayout)
+	}
+
+	b := &strings.Builder{}
+
+	fmt.Fprintf(b, "<%d>1 %s %s %s %s %s ",
+		e.opts.Facility*8+syslogSeverity(event.Severity),
+		timestamp,
+		syslogHeaderField(e.opts.Hostname, 255),
+		syslogHeaderField(e.opts.AppName, 48),
+		"-",
+		syslogHeaderField(string(event.Event), 32),
+	)
+
+	e.writeElement(b, "user", "name", event.User, "uuid", event.UserUuid)
+	e.writeElement(b, "computer", "name", event.Computer)
+	e.writeElement(b, "application", "name", string(event.Application))
+	e.writeElement(b, "event", "type", string(event.Event), "severity", string(event.Severity),
+		"transactionStatus", string(event.TransactionStatus))
+	e.writeElement(b, "metadata", "name", event.Metadata, "uuid", event.MetadataUuid)
+	e.writeElement(b, "session", "id", strconv.FormatInt(event.Session, 10),
+		"connection", strconv.FormatInt(event.Connection, 10))
+	e.writeElement(b, "journal", "key", event.Key())
+
+	message := event.Comment
+	if len(message) == 0 {
+		message = event.DataPresentation
+	}
+
+	if len(message) > 0 {
+		// Текст сообщения в UTF-8 начинается с BOM
+		b.WriteString(" \xef\xbb\xbf")
+		b.WriteString(message)
+	}
+
+	return []byte(b.String())
+}
+
+// writeElement дописывает элемент структурированных данных с парами имя-значение.
+// Пустые параметры пропускаются, элемент без параметров не пишется
+func (e *SyslogExporter) writeElement(b *strings.Builder, id string, params ...string) {
+
+	var values []string
+
+	for i := 0; i+1 < len(params); i += 2 {
+		if len(params[i+1]) == 0 {
+			continue
+		}
+		values = append(values, params[i]+`="`+syslogParamValue(params[i+1])+`"`)
+	}
+
+	if len(values) == 0 {
+		return
+	}
+
+	b.WriteString("[" + id + "@" + e.opts.Enterprise + " " + strings.Join(values, " ") + "]")
+}
+
+func (e *SyslogExporter) send(events []eventlog.Event) error {
+
+	messages := make([][]byte, 0, len(events))
+	for _, event := range events {
+		messages = append(messages, e.Format(event))
+	}
+
+	sent := 0
+
+	err := retry(e.opts.MaxRetries, e.opts.MinBackoff, e.opts.MaxBackoff, func() error {
+
+		for sent < len(messages) {
+			if err := e.write(messages[sent]); err != nil {
+				return err
+			}
+			sent++
+		}
+
+		return nil
+	})
+
+	if err == nil {
+		return nil
+	}
+
+	err = fmt.Errorf("syslog exporter: %d messages not sent: %w", len(messages)-sent, err)
+	log.Print(err)
+
+	if dlErr := e.deadLetter.Write(events[sent:]); dlErr != nil {
+		log.Printf("syslog exporter: write dead letter: %s", dlErr)
+	}
+
+	return err
+}
+
+// WriteEvents отправляет пачку событий без повторных попыток.
+// При ошибке часть сообщений пачки может быть уже отправлена
+func (e *SyslogExporter) WriteEvents(events []eventlog.Event) error {
+
+	for _, event := range events {
+		if err := e.write(e.Format(event)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// write отправляет одно сообщение, при необходимости устанавливая соединение.
+// После ошибки соединение закрывается и при следующей попытке устанавливается заново
+func (e *SyslogExporter) write(message []byte) error {
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.conn == nil {
+		conn, err := e.dial()
+		if err != nil {
+			return err
+		}
+		e.conn = conn
+	}
+
+	frame := message
+	if e.opts.Network != "udp" {
+		frame = append([]byte(strconv.Itoa(len(message))+" "), message...)
+	}
+
+	_ = e.conn.SetWriteDeadline(time.Now().Add(e.opts.WriteTimeout))
+
+	if _, err := e.conn.Write(frame); err != nil {
+		_ = e.conn.Close()
+		e.conn = nil
+		return err
+	}
+
+	return nil
+}
+
+func (e *SyslogExporter) dial() (net.Conn, error) {
+
+	dialer := &net.Dialer{Timeout: e.opts.DialTimeout}
+
+	if e.opts.Network == "tls" {
+		return tls.DialWithDialer(dialer, "tcp", e.opts.Address, e.opts.TLSConfig)
+	}
+
+	return dialer.Dial(e.opts.Network, e.opts.Address)
+}
+
+// syslogSeverity переводит важность события в уровень syslog
+func syslogSeverity(severity eventlog.SeverityType) int {
+
+	switch severity {
+	case eventlog.SeverityError:
+		return syslogError
+	case eventlog.SeverityWarn:
+		return syslogWarning
+	case eventlog.SeverityNote:
+		return syslogNotice
+	default:
+		return syslogInfo
+	}
+}
+
+// syslogHeaderField оставляет в поле заголовка только печатные ASCII символы без пробелов
+func syslogHeaderField(value string, max int) string {
+
+	b := make([]byte, 0, len(value))
+
+	for i := 0; i < len(value) && len(b) < max; i++ {
+		if c := value[i]; c > 32 && c < 127 {
+			b = append(b, c)
+		}
+	}
+
+	if len(b) == 0 {
+		return "-"
+	}
+
+	return string(b)
+}
+
+var syslogParamReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+func syslogParamValue(value string) string {
+	return syslogParamReplacer.Replace(value)
+}
@@ -0,0 +1,269 @@
+package exporter
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/v8platform/eventlog"
+)
+
+func TestSyslogExporter_Format(t *testing.T) {
+
+	e, err := NewSyslogExporter(SyslogOptions{
+		Address:  "127.0.0.1:514",
+		Hostname: "srv 1c",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+
+	event := testEvents(1)[0]
+	event.Severity = eventlog.SeverityError
+	event.Computer = "PC-01"
+	event.Application = eventlog.ApplicationDesigner
+	event.Metadata = `Справочник.Номенклатура`
+	event.Comment = `Ошибка "доступа" [1]`
+
+	got := string(e.Format(event))
+
+	want := `<131>1 2021-01-08T10:24:40.000000Z srv1c 1cv8-eventlog - _$Data$_.New ` +
+		`[user@32473 name="Администратор"]` +
+		`[computer@32473 name="PC-01"]` +
+		`[application@32473 name="Designer"]` +
+		`[event@32473 type="_$Data$_.New" severity="E"]` +
+		`[metadata@32473 name="Справочник.Номенклатура"]` +
+		`[session@32473 id="0" connection="0"]` +
+		`[journal@32473 key="5e9a7aa8-4efa-11e9-a98f-005056aea130::100"]` +
+		" \xef\xbb\xbf" + `Ошибка "доступа" [1]`
+
+	if got != want {
+		t.Errorf("message:\n%s\nwant:\n%s", got, want)
+	}
+
+	event.Event = "Событие пользователя"
+	if got := string(e.Format(event)); !strings.Contains(got, " 1cv8-eventlog - - [") {
+		t.Errorf("non ascii msgid: %s", got)
+	}
+}
+
+func TestSyslogExporter_UDP(t *testing.T) {
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	e, err := NewSyslogExporter(SyslogOptions{
+		Network: "udp",
+		Address: conn.LocalAddr().String(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, event := range testEvents(3) {
+		e.Push(event)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	buf := make([]byte, 4096)
+	for i := 0; i < 3; i++ {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(string(buf[:n]), "<134>1 ") {
+			t.Errorf("datagram %d: %s", i, buf[:n])
+		}
+	}
+}
+
+// readFrames читает сообщения, разделенные указанием длины, и передает их в канал
+func readFrames(conn net.Conn, messages chan<- string) {
+
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+
+	for {
+		size, err := r.ReadString(' ')
+		if err != nil {
+			return
+		}
+
+		n, err := strconv.Atoi(strings.TrimSpace(size))
+		if err != nil {
+			return
+		}
+
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return
+		}
+
+		messages <- string(buf)
+	}
+}
+
+func TestSyslogExporter_TCPReconnect(t *testing.T) {
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	messages := make(chan string, 100)
+	accepted := make(chan net.Conn, 10)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- conn
+			go readFrames(conn, messages)
+		}
+	}()
+
+	e, err := NewSyslogExporter(SyslogOptions{
+		Network:    "tcp",
+		Address:    listener.Addr().String(),
+		MinBackoff: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+
+	events := testEvents(50)
+
+	e.Push(events[0])
+	if err := e.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if msg := <-messages; !strings.Contains(msg, `key="5e9a7aa8-4efa-11e9-a98f-005056aea130::100"`) {
+		t.Fatalf("first message: %s", msg)
+	}
+
+	// Сервер разрывает соединение, экспортер должен подключиться заново
+	(<-accepted).Close()
+
+	deadline := time.After(5 * time.Second)
+
+	for _, event := range events[1:] {
+
+		e.Push(event)
+		if err := e.Flush(); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case <-accepted:
+			return
+		case <-deadline:
+			t.Fatal("exporter did not reconnect")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	t.Fatal("exporter did not reconnect")
+}
+
+func testCertificate(t *testing.T) tls.Certificate {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestSyslogExporter_TLS(t *testing.T) {
+
+	cert := testCertificate(t)
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	messages := make(chan string, 10)
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		readFrames(conn, messages)
+	}()
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(leaf)
+
+	e, err := NewSyslogExporter(SyslogOptions{
+		Network:   "tls",
+		Address:   listener.Addr().String(),
+		TLSConfig: &tls.Config{RootCAs: roots},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, event := range testEvents(2) {
+		e.Push(event)
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case msg := <-messages:
+			if !strings.HasPrefix(msg, "<134>1 ") {
+				t.Errorf("message %d: %s", i, msg)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("message not received")
+		}
+	}
+}
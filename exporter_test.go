@@ -0,0 +1,115 @@
+package eventlog
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// counterReader бесконечный читатель, позиция которого равна количеству прочитанных событий
+type counterReader struct {
+	mu     sync.Mutex
+	offset int64
+}
+
+func (r *counterReader) Read(limit int, _ time.Duration) ([]Event, error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	events := make([]Event, limit)
+	r.offset += int64(limit)
+
+	return events, nil
+}
+
+func (r *counterReader) Offset() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.offset
+}
+
+func (r *counterReader) Seek(offset int64) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.offset = offset
+	return offset, nil
+}
+
+func (r *counterReader) Close() error {
+	return nil
+}
+
+type flushStorage struct {
+	syncStorage
+	flushed int
+}
+
+func (s *flushStorage) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flushed++
+	return nil
+}
+
+func TestExporter_Stop(t *testing.T) {
+
+	reader := &counterReader{}
+	storage := &flushStorage{}
+
+	exporter := NewExporter(reader, []ExporterStorage{storage}, ExporterConfig{
+		Poller: &LongPoller{Limit: 10},
+	})
+
+	started := make(chan struct{})
+	go func() {
+		close(started)
+		exporter.Start()
+	}()
+	<-started
+
+	for storage.Len() < 25 {
+		time.Sleep(time.Millisecond)
+	}
+
+	offset, err := exporter.Stop()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if int64(storage.Len()) != offset {
+		t.Errorf("pushed %d events, offset %d", storage.Len(), offset)
+	}
+	if offset%10 != 0 {
+		t.Errorf("offset %d is not at batch boundary", offset)
+	}
+	if storage.flushed != 1 {
+		t.Errorf("flushed %d times, want 1", storage.flushed)
+	}
+}
+
+func TestExporter_StopNotStarted(t *testing.T) {
+
+	reader := &counterReader{offset: 42}
+	exporter := NewExporter(reader, nil, ExporterConfig{Poller: &LongPoller{Limit: 10}})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if offset, err := exporter.Stop(); err != nil || offset != 42 {
+			t.Errorf("Stop() = %d, %v", offset, err)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop blocked without Start")
+	}
+
+	// Start после Stop не читает события
+	exporter.Start()
+	if reader.Offset() != 42 {
+		t.Errorf("offset after Start = %d, want 42", reader.Offset())
+	}
+}
@@ -0,0 +1,82 @@
+package eventlog
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
+)
+
+var _ JournalStorage = (*FileJournal)(nil)
+
+// FileJournal хранит позиции файлов журналов регистрации в JSON файле,
+// чтобы после перезапуска менеджер продолжал чтение с прошлых позиций.
+// Файл перезаписывается при каждом изменении позиции
+type FileJournal struct {
+	file string
+
+	mu      sync.Mutex
+	offsets map[string]int64
+}
+
+// NewFileJournal создает хранилище позиций и загружает ранее сохраненные позиции из file
+func NewFileJournal(file string) (*FileJournal, error) {
+
+	j := &FileJournal{
+		file:    file,
+		offsets: make(map[string]int64),
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &j.offsets); err != nil {
+		return nil, err
+	}
+
+	return j, nil
+}
+
+func (j *FileJournal) GetOffset(file string) int64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.offsets[file]
+}
+
+func (j *FileJournal) SetOffset(file string, off int64) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if current, ok := j.offsets[file]; ok && current == off {
+		return
+	}
+	j.offsets[file] = off
+
+	if err := j.save(); err != nil {
+		log.Printf("file journal: %s", err)
+	}
+}
+
+// save записывает позиции во временный файл и заменяет им старый
+func (j *FileJournal) save() error {
+
+	data, err := json.Marshal(j.offsets)
+	if err != nil {
+		return err
+	}
+
+	tmp := j.file + ".tmp"
+
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, j.file)
+}
@@ -0,0 +1,34 @@
+package eventlog
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFileJournal(t *testing.T) {
+
+	file := filepath.Join(t.TempDir(), "offsets.json")
+
+	j, err := NewFileJournal(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if off := j.GetOffset("a.lgp"); off != 0 {
+		t.Errorf("offset of new file = %d", off)
+	}
+
+	j.SetOffset("a.lgp", 157)
+	j.SetOffset("b.lgp", 714)
+
+	// Позиции сохраняются между запусками
+	j, err = NewFileJournal(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if off := j.GetOffset("a.lgp"); off != 157 {
+		t.Errorf("a.lgp offset = %d, want 157", off)
+	}
+	if off := j.GetOffset("b.lgp"); off != 714 {
+		t.Errorf("b.lgp offset = %d, want 714", off)
+	}
+}
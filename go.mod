@@ -3,9 +3,14 @@ module github.com/v8platform/eventlog
 go 1.16
 
 require (
+	github.com/fsnotify/fsnotify v1.5.1
 	github.com/k0kubun/colorstring v0.0.0-20150214042306-9440f1994b88 // indirect
 	github.com/k0kubun/pp v3.0.1+incompatible
+	github.com/mattn/go-sqlite3 v1.14.16
 	github.com/radovskyb/watcher v1.0.7
 	github.com/v8platform/brackets v0.3.0
 	github.com/xelaj/go-dry v0.0.0-20201114160035-4f99d0d557b8
+	google.golang.org/grpc v1.40.0
+	google.golang.org/protobuf v1.27.1
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -5,8 +5,14 @@ import (
 	"time"
 )
 
+// Смещение SecondsToUnixTime. Значение на секунду больше количества секунд с 01.01.0001 до 01.01.1970
+// и сохранено для совместимости, DecodeTransactionDate его не использует
 const unixTimeInSeconds = 62135596801
 
+// SecondsToUnixTime переводит количество секунд с 01.01.0001 во время по UTC.
+// Результат на секунду меньше точного значения, поведение сохранено для существующих вызовов.
+//
+// Deprecated: дробная часть секунд теряется, используйте DecodeTransactionDate
 func SecondsToUnixTime(seconds int) time.Time {
 
 	if seconds == 0 {
@@ -5,6 +5,9 @@ import (
 	"github.com/v8platform/brackets"
 	"io"
 	"log"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 )
 
@@ -220,3 +223,53 @@ type RefObject struct {
 	Uuid  string
 	Value string
 }
+
+// DictionaryItem элемент справочника файла 1Cv8.lgf
+type DictionaryItem struct {
+	Id    int
+	Uuid  string
+	Value string
+}
+
+// Dictionary дочитывает файл 1Cv8.lgf и возвращает все элементы справочника указанного типа,
+// упорядоченные по идентификатору
+func (r *LgfReader) Dictionary(objectType int) []DictionaryItem {
+
+	r.readTill(ObjectTypeNone)
+
+	prefix := getKeyValue(objectType) + "."
+
+	var items []DictionaryItem
+
+	r.objects.Range(func(key, value interface{}) bool {
+
+		k := key.(string)
+		if !strings.HasPrefix(k, prefix) {
+			return true
+		}
+
+		id, err := strconv.Atoi(strings.TrimPrefix(k, prefix))
+		if err != nil {
+			// Составные ключи (значения разделителей данных) не поддерживаются
+			return true
+		}
+
+		item := DictionaryItem{Id: id}
+
+		switch v := value.(type) {
+		case []string:
+			item.Value, item.Uuid = v[0], v[1]
+		case string:
+			item.Value = v
+		}
+
+		items = append(items, item)
+		return true
+	})
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Id < items[j].Id
+	})
+
+	return items
+}
@@ -20,62 +20,137 @@ var _ EventReader = (*LgpReader)(nil)
 type LgpReaderOptions struct {
 	LgfDir    string
 	LgfFile   string
-	LgfStream io.ReadSeekCloser
+	LgfStream io.ReadSeekCloser // Закрывается при закрытии читателя
 	LgfOffset int64
 	Offset    int64
+	Location  *time.Location // Временная зона сервера, по умолчанию time.Local
 }
 
 type LgpReader struct {
-	stream  io.ReadSeekCloser
-	parser  *brackets.Parser
-	objects Objects
-	offset  int64
-	Uuid    string
-	Version string
+	stream      io.ReadSeekCloser
+	lgf         io.Closer
+	tail        *tailReader
+	parser      *brackets.Parser
+	objects     Objects
+	offset      int64
+	clock       *localClock
+	file        string
+	infobaseDir string
+	Uuid        string
+	Version     string
 }
 
+// Close закрывает файлы lgp и lgf читателя
 func (r *LgpReader) Close() error {
+
 	err := r.stream.Close()
-	if err != nil {
-		return err
+
+	if r.lgf != nil {
+		if lgfErr := r.lgf.Close(); err == nil {
+			err = lgfErr
+		}
 	}
-	return nil
+
+	return err
 }
 
 func (r *LgpReader) Seek(offset int64) (int64, error) {
 
 	if r.offset == offset {
-		return 0, nil
+		return offset, nil
 	}
 
+	return r.reset(offset)
+}
+
+// reset переставляет поток на нужную позицию и пересоздает парсер,
+// т.к. парсер буферизирует прочитанные данные
+func (r *LgpReader) reset(offset int64) (int64, error) {
+
 	n, err := r.stream.Seek(offset, io.SeekStart)
 	if err != nil {
 		return n, err
 	}
 
-	r.offset = offset
+	r.tail = &tailReader{r: r.stream}
+	r.parser = brackets.NewParser(r.tail)
+	r.offset = n
 
 	return n, nil
 }
 
+// tailReader отмечает, что при чтении был достигнут конец файла
+type tailReader struct {
+	r   io.Reader
+	eof bool
+}
+
+func (t *tailReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	if err == io.EOF {
+		t.eof = true
+	}
+	return n, err
+}
+
+// nextNode возвращает следующее событие файла.
+// Событие, которое еще не дописано до конца, не возвращается, а парсер переставляется
+// на его начало, чтобы при следующем чтении событие прочиталось целиком
+func (r *LgpReader) nextNode() (node brackets.Node, n int) {
+
+	r.tail.eof = false
+
+	defer func() {
+		if err := recover(); err != nil {
+			// Парсер не разбирает оборванный текст события
+			if !r.tail.eof {
+				panic(err)
+			}
+			node, n = nil, 0
+		}
+		if node == nil && r.tail.eof {
+			_, _ = r.reset(r.offset)
+		}
+	}()
+
+	node, n = r.parser.NextNode()
+
+	// Парсер дошел до конца файла, не найдя конец события
+	if r.tail.eof {
+		return nil, 0
+	}
+
+	return node, n
+}
+
+// SetLocation устанавливает временную зону сервера, в которой записаны события
+func (r *LgpReader) SetLocation(loc *time.Location) {
+	r.clock = newLocalClock(loc)
+}
+
 func (r *LgpReader) Offset() int64 {
 
 	return r.offset
 }
 
-func (r *LgpReader) readMetadata() {
+func (r *LgpReader) readMetadata() error {
 
 	br := bufio.NewReader(r.stream)
 
 	versionBytes, _ := br.ReadBytes('\n')
 	uuidString, _ := br.ReadString('\n')
+
+	// Заголовок занимает две строки, остальное прочитанное в буфер
+	// относится уже к событиям
+	headerSize := int64(len(versionBytes) + len(uuidString))
+
 	versionBytes = bytes.Trim(versionBytes, "\xef\xbb\xbf")
 
 	r.Version = strings.TrimSpace(string(versionBytes))
 	r.Uuid = strings.TrimSpace(uuidString)
 
-	r.offset, _ = r.stream.Seek(0, io.SeekCurrent)
-
+	_, err := r.reset(headerSize)
+	return err
 }
 
 func (r *LgpReader) Read(limit int, timeout time.Duration) (items []Event, err error) {
@@ -101,51 +176,61 @@ func (r *LgpReader) read(ctx context.Context, limit int, timeout time.Duration)
 		timeoutC = time.After(timeout)
 	}
 
-	var count int
-	mu := &sync.Mutex{}
+	// События разбираются параллельно, но возвращаются
+	// в порядке их следования в файле
+	var events []*Event
 	wg := &sync.WaitGroup{}
+
+	defer func() {
+		wg.Wait()
+		for _, event := range events {
+			event.Date = r.clock.Resolve(event.Date)
+			event.DateUTC = event.Date.UTC()
+			event.TransactionDate = r.clock.ResolveBefore(event.TransactionDate, event.Date)
+			event.JournalFile = r.file
+			event.JournalUUID = r.Uuid
+			event.JournalVersion = r.Version
+			event.InfobaseDir = r.infobaseDir
+			items = append(items, *event)
+		}
+	}()
+
 	//limiter := make(chan struct{}, 10)
 	for {
 		select {
 		case <-ctx.Done():
-			wg.Wait()
-			return items, ctx.Err()
+			return nil, ctx.Err()
 		case <-timeoutC:
-			wg.Wait()
-			return
+			return nil, nil
 		default:
 
-			if limit > 0 && count == limit {
-				wg.Wait()
-				return items, nil
+			if limit > 0 && len(events) == limit {
+				return nil, nil
 			}
 
 			//limiter <-empty
-			node, n := r.parser.NextNode()
+			node, n := r.nextNode()
 			start := r.offset
-			r.offset += int64(n)
 			if node == nil {
-				wg.Wait()
-				return items, io.EOF
+				// Хвост без события не учитываем,
+				// чтобы дописанное позже событие прочиталось целиком
+				return nil, io.EOF
 			}
+			r.offset += int64(n)
 
-			count++
-			wg.Add(1)
-
-			go func(n brackets.Node, offset, size int64) {
+			event := &Event{
+				Offset: start,
+				Size:   int64(n),
+			}
+			events = append(events, event)
 
-				event := &Event{
-					Offset: offset,
-					Size:   size,
-				}
+			wg.Add(1)
 
-				parseEventLogItemData(event, n, r.objects)
-				mu.Lock()
-				defer mu.Unlock()
+			go func(n brackets.Node, event *Event) {
 				defer wg.Done()
-				items = append(items, *event)
+				parseEventLogItemData(event, n, r.objects)
 				//<-limiter
-			}(node, start, int64(n))
+			}(node, event)
 
 		}
 	}
@@ -172,28 +257,58 @@ func NewLgpReader(path string, opts ...LgpReaderOptions) (*LgpReader, error) {
 	lgfStream, err := getLgfFile(options)
 
 	if err != nil {
+		_ = lgpStream.Close()
 		return nil, err
 	}
 
 	reader := &LgpReader{
-		stream:  lgpStream,
-		parser:  brackets.NewParser(lgpStream),
-		objects: NewLgfReader(lgfStream),
+		stream:      lgpStream,
+		lgf:         lgfStream,
+		objects:     NewLgfReader(lgfStream),
+		clock:       newLocalClock(options.Location),
+		file:        path,
+		infobaseDir: journalInfobaseDir(filepath.Dir(path)),
 	}
 
-	reader.readMetadata()
+	if err := reader.readMetadata(); err != nil {
+		reader.closeOnError(options)
+		return nil, err
+	}
 
 	if options.Offset > 0 {
-		if _, err := lgpStream.Seek(options.Offset, io.SeekStart); err != nil {
+		if _, err := reader.Seek(options.Offset); err != nil {
+			reader.closeOnError(options)
 			return nil, err
 		}
-		reader.offset = options.Offset
 	}
 
 	return reader, nil
 
 }
 
+// closeOnError закрывает файлы читателя, который не удалось создать.
+// Переданный в настройках поток lgf закрывает вызывающий код
+func (r *LgpReader) closeOnError(options LgpReaderOptions) {
+
+	_ = r.stream.Close()
+
+	if options.LgfStream == nil {
+		_ = r.lgf.Close()
+	}
+}
+
+// journalInfobaseDir возвращает каталог информационной базы по каталогу журнала регистрации.
+// Журнал хранится в подкаталоге 1Cv8Log каталога информационной базы,
+// для журналов в других каталогах возвращается сам каталог журнала
+func journalInfobaseDir(dir string) string {
+
+	if strings.EqualFold(filepath.Base(dir), "1Cv8Log") {
+		return filepath.Dir(dir)
+	}
+
+	return dir
+}
+
 func getLgfFile(opt LgpReaderOptions) (io.ReadSeekCloser, error) {
 
 	switch {
@@ -225,7 +340,8 @@ func getLgfFile(opt LgpReaderOptions) (io.ReadSeekCloser, error) {
 
 func parseEventLogItemData(event *Event, parsedData brackets.Node, objects Objects) {
 
-	event.Date, _ = time.Parse(`20060102150405`, parsedData.Get(0))
+	// Время записи без зоны, перевод в зону сервера выполняет читатель
+	event.Date, _ = time.Parse(eventDateLayout, parsedData.Get(0))
 
 	event.TransactionStatus = TransactionStatusType(parsedData.Get(1))
 	event.TransactionNumber, event.TransactionDate = getTransactionData(parsedData.GetNode(2))
@@ -344,12 +460,11 @@ func getData(node brackets.Node, eventType EventType) interface{} {
 	}
 }
 
-// Конвертация во время далась очень сложно только через unix
+// getTransactionData возвращает номер и время начала транзакции.
+// Время транзакции без зоны, перевод в зону сервера выполняет читатель
 func getTransactionData(data brackets.Node) (int64, time.Time) {
 
-	seconds := int(From16To10(data.Get(0)) / 10000)
-
-	transactionDate := SecondsToUnixTime(seconds)
+	transactionDate := DecodeTransactionDate(data.Get(0))
 	transactionNumber := From16To10(data.Get(1))
 
 	return transactionNumber, transactionDate
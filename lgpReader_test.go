@@ -2,6 +2,8 @@ package eventlog
 
 import (
 	"github.com/k0kubun/pp"
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -64,3 +66,117 @@ func TestLgpReader_Offset(t *testing.T) {
 		})
 	}
 }
+
+func TestLgpReader_JournalFields(t *testing.T) {
+
+	r, err := NewLgpReader("./tests/20210108100000.lgp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	events, err := r.Read(1, 0)
+	if err != nil || len(events) != 1 {
+		t.Fatalf("read %d events: %v", len(events), err)
+	}
+
+	event := events[0]
+
+	if event.JournalFile != "./tests/20210108100000.lgp" || event.JournalUUID != r.Uuid || len(event.JournalUUID) == 0 {
+		t.Errorf("journal = <%s> <%s>", event.JournalFile, event.JournalUUID)
+	}
+	if event.JournalVersion != r.Version || len(event.JournalVersion) == 0 {
+		t.Errorf("version = <%s>", event.JournalVersion)
+	}
+	if event.InfobaseDir != "tests" {
+		t.Errorf("infobase dir = <%s>", event.InfobaseDir)
+	}
+}
+
+func TestJournalInfobaseDir(t *testing.T) {
+
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{"/srv/1cv8/reg_1541/a8f8f5d2-9d1b-4c73-9c4f-5d1c7f0a1e11/1Cv8Log", "/srv/1cv8/reg_1541/a8f8f5d2-9d1b-4c73-9c4f-5d1c7f0a1e11"},
+		{"/home/user/ib/1cv8log", "/home/user/ib"},
+		{"/var/log/eventlog", "/var/log/eventlog"},
+	}
+
+	for _, tt := range tests {
+		if got := journalInfobaseDir(tt.dir); got != tt.want {
+			t.Errorf("journalInfobaseDir(%s) = %s, want %s", tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestLgpReader_PartialEvent(t *testing.T) {
+
+	_, file, tail := copyTestJournal(t, 1000)
+
+	r, err := NewLgpReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	// Недописанное событие в конце файла не читается
+	for {
+		if _, err := r.Read(100, 0); err != nil {
+			break
+		}
+	}
+	if got := r.Offset(); got != 984 {
+		t.Fatalf("Offset() = %d, want 984", got)
+	}
+
+	appendFile(t, file, tail)
+
+	events, err := r.Read(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Offset != 984 {
+		t.Fatalf("event after append = %+v, want event at 984", events)
+	}
+}
+
+// openFiles возвращает количество открытых файлов процесса
+func openFiles(t *testing.T) int {
+
+	fds, err := ioutil.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Skip("open files are not available: ", err)
+	}
+
+	return len(fds)
+}
+
+func TestLgpReader_CloseFiles(t *testing.T) {
+
+	dir, file, _ := copyTestJournal(t, 1000)
+	missing := LgpReaderOptions{LgfFile: filepath.Join(dir, "missing.lgf")}
+
+	before := openFiles(t)
+
+	for i := 0; i < 10; i++ {
+
+		r, err := openLgpReader(file, 0, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		// Файлы закрываются и при ошибке создания читателя
+		if _, err := NewLgpReader(file, missing); err == nil {
+			t.Fatal("expected error for missing lgf")
+		}
+	}
+
+	if after := openFiles(t); after != before {
+		t.Errorf("open files = %d, want %d", after, before)
+	}
+}
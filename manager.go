@@ -3,11 +3,11 @@ package eventlog
 import (
 	"context"
 	"errors"
-	"github.com/radovskyb/watcher"
-	"github.com/xelaj/go-dry"
 	"log"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"sync"
 	"time"
 )
@@ -29,6 +29,8 @@ import (
 */
 const lgfFileName = "1Cv8.lgf"
 
+const defaultIdleCheckFrequency = 5 * time.Minute
+
 type ManagerOptions struct {
 	Timeout            time.Duration
 	Folder             []string
@@ -37,9 +39,16 @@ type ManagerOptions struct {
 	JournalStorage     JournalStorage
 	Exporters          []ExporterStorage
 	BulkSize           int
+	TZ                 *time.Location
+	Notifier           FileNotifier // Уведомления об изменениях файлов, по умолчанию NewFileNotifier
+	Backfill           bool         // Выгрузка существующих файлов журналов при запуске
+	BackfillPoolSize   int          // Лимит одновременно выгружаемых при запуске файлов, по умолчанию 1
+	Queue              QueueOptions // Очередь перед каждым хранилищем событий
+	RateLimit          float64      // Лимит чтений файла журнала в секунду, 0 - без ограничения
 }
 
-func createLgpExporter(file string, offset int64, storage []ExporterStorage, poller Poller, tz *time.Location) (*Exporter, error) {
+// openLgpReader открывает файл журнала с указанной позиции
+func openLgpReader(file string, offset int64, tz *time.Location) (*LgpReader, error) {
 
 	lgfDir := filepath.Dir(file)
 	LgfFile := filepath.Join(lgfDir, lgfFileName)
@@ -54,43 +63,72 @@ func createLgpExporter(file string, offset int64, storage []ExporterStorage, pol
 		LgfFile:   LgfFile,
 		LgfStream: lgfStream,
 		Offset:    offset,
+		Location:  tz,
 	}
 
 	reader, err := NewLgpReader(file, lgpOpts)
-
 	if err != nil {
+		_ = lgfStream.Close()
 		return nil, err
 	}
 
-	exporter := NewExporter(reader, storage)
-	exporter.Poller = poller
-	exporter.TZ = tz
-
-	return exporter, nil
-
+	return reader, nil
 }
 
+// NewManager создает менеджер журналов регистрации. Обработка начинается после вызова Start
+// и завершается при отмене ctx или вызове Shutdown
 func NewManager(ctx context.Context, opt ManagerOptions) *Manager {
 
+	poolSize := opt.PoolSize
+	if poolSize <= 0 {
+		poolSize = runtime.NumCPU()
+	}
+	bulkSize := opt.BulkSize
+	if bulkSize <= 0 {
+		bulkSize = defaultBulkSize
+	}
+	backfillPoolSize := opt.BackfillPoolSize
+	if backfillPoolSize <= 0 {
+		backfillPoolSize = 1
+	}
+	idleCheckFrequency := opt.IdleCheckFrequency
+	if idleCheckFrequency <= 0 {
+		idleCheckFrequency = defaultIdleCheckFrequency
+	}
+
 	p := &Manager{
-		queue:       make(chan struct{}, opt.PoolSize),
-		BulkSize:    opt.BulkSize,
-		fileWatcher: watcher.New(),
-		exporters:   map[string]*Exporter{},
-		mu:          sync.Mutex{},
-		stop:        make(chan struct{}),
-		journals:    NewInMemoryJournal(),
-		Ticker:      2 * time.Second,
+		ctx:                ctx,
+		poolSize:           poolSize,
+		Folder:             opt.Folder,
+		BulkSize:           bulkSize,
+		Timeout:            opt.Timeout,
+		RateLimit:          opt.RateLimit,
+		IdleCheckFrequency: idleCheckFrequency,
+		Backfill:           opt.Backfill,
+		backfillSlots:      make(chan struct{}, backfillPoolSize),
+		backfillCancels:    map[string]context.CancelFunc{},
+		notifier:           opt.Notifier,
+		queueOptions:       opt.Queue,
+		exporters:          map[string]*Exporter{},
+		readers:            map[string]*journalReader{},
+		mu:                 sync.Mutex{},
+		stop:               make(chan struct{}),
+		processed:          make(chan struct{}),
+		journals:           NewInMemoryJournal(),
+		Ticker:             2 * time.Second,
+		TZ:                 opt.TZ,
 	}
 
 	if opt.JournalStorage != nil {
 		p.journals = opt.JournalStorage
 	}
-	p.fileWatcher.AddFilterHook(filterFiles)
-	//fileWatcher.SetMaxEvents(1)
-	p.fileWatcher.FilterOps(watcher.Create, watcher.Write, watcher.Remove)
-
-	go p.process(ctx)
+	for _, storage := range opt.Exporters {
+		p.AddStorage(storage)
+	}
+	p.scheduler = newFileScheduler(poolSize, p.exportFile)
+	if p.notifier == nil {
+		p.notifier = NewFileNotifier(p.Ticker)
+	}
 
 	return p
 }
@@ -123,20 +161,6 @@ func (i InMemoryJournal) SetOffset(file string, off int64) {
 	i.data.Store(file, off)
 }
 
-func extFilterHook(ext ...string) watcher.FilterFileHookFunc {
-	return func(info os.FileInfo, fullPath string) error {
-
-		if !info.IsDir() && dry.StringListContains(ext, filepath.Ext(info.Name())) {
-			return nil
-		}
-
-		// No match.
-		return watcher.ErrSkip
-	}
-}
-
-var filterFiles = extFilterHook(".lgp", ".lgd")
-
 // Manager основной объект выполнения чтения и экспорта журналов регистрации
 type Manager struct {
 	poolSize int      // Лимит обновременных экспортеров
@@ -147,162 +171,627 @@ type Manager struct {
 	Ticker   time.Duration
 	TZ       *time.Location
 
-	fileWatcher *watcher.Watcher
+	RateLimit float64 // Лимит чтений файла журнала в секунду, 0 - без ограничения
+
+	// Период проверки открытых читателей. Читатели файлов, в которые
+	// не было записи дольше этого периода, закрываются
+	IdleCheckFrequency time.Duration
+
+	// Выгрузка существующих файлов отслеживаемых каталогов при запуске.
+	// Файлы каждого журнала читаются от старых к новым с сохраненных позиций,
+	// последний файл журнала после этого обрабатывается в обычном режиме
+	Backfill        bool
+	backfillSlots   chan struct{}
+	backfills       sync.WaitGroup
+	backfillCancels map[string]context.CancelFunc // Отмена выгрузки по каталогам для Unwatch
+
+	notifier FileNotifier
+	watched  []string // Каталоги, добавленные через Watch
 
 	journals  JournalStorage
 	mu        sync.Mutex
 	exporters map[string]*Exporter
+	readers   map[string]*journalReader // Открытые читатели файлов между проходами
+	scheduler *fileScheduler
+
+	// Хранилища событий, каждое за своей очередью
+	storage      []ExporterStorage
+	queues       []*QueuedStorage
+	queueOptions QueueOptions
+
+	ctx       context.Context // Контекст работы менеджера, отменяется при остановке
+	cancel    context.CancelFunc
+	started   bool
+	running   bool
+	stopOnce  sync.Once
+	stop      chan struct{} // Закрывается после полной остановки
+	processed chan struct{} // Закрывается после завершения обработки уведомлений
+}
+
+var (
+	ErrManagerStarted = errors.New("manager already started")
+	ErrManagerStopped = errors.New("manager stopped")
+)
+
+// Start запускает отслеживание каталогов журналов.
+// Возвращает ошибку, если отслеживание не удалось запустить
+func (m *Manager) Start() error {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	select {
+	case <-m.stop:
+		return ErrManagerStopped
+	default:
+	}
+
+	if m.started {
+		return ErrManagerStarted
+	}
+
+	parent := m.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	m.ctx, m.cancel = context.WithCancel(parent)
+
+	// Период опроса каталогов можно изменить до запуска
+	if n, ok := m.notifier.(intervalSetter); ok {
+		n.SetInterval(m.Ticker)
+	}
+
+	for _, folder := range m.Folder {
+		if err := m.notifier.Add(folder); err != nil {
+			m.cancel()
+			return err
+		}
+	}
+
+	if err := m.notifier.Start(); err != nil {
+		m.cancel()
+		return err
+	}
+
+	m.started = true
+	m.running = true
 
-	queue chan struct{}
+	go m.process()
+	go m.checkIdle(m.ctx, m.IdleCheckFrequency)
 
-	storage []ExporterStorage
-	stop    chan struct{}
+	if m.Backfill {
+		for _, folder := range m.Folder {
+			m.backfillFolder(folder)
+		}
+		for _, folder := range m.watched {
+			m.backfillFolder(folder)
+		}
+	}
 
-	running bool
+	return nil
 }
 
+// Wait ожидает полной остановки менеджера
 func (m *Manager) Wait() {
 	<-m.stop
 }
 
+// Stop останавливает менеджер и ожидает завершения работающих экспортеров
 func (m *Manager) Stop() {
-	close(m.stop)
-	m.fileWatcher.Close()
+	_ = m.Shutdown(context.Background())
+}
+
+// Shutdown прекращает отслеживание каталогов и останавливает работающие экспортеры.
+// Экспортеры передают в хранилища текущую партию событий и фиксируют позиции.
+// Если ctx завершится раньше, то возвращается его ошибка, а экспортеры завершатся в фоне
+func (m *Manager) Shutdown(ctx context.Context) error {
+
+	m.stopOnce.Do(func() {
+
+		m.mu.Lock()
+		started := m.started
+		if m.cancel != nil {
+			m.cancel()
+		}
+		m.mu.Unlock()
+
+		if !started {
+			if err := m.notifier.Close(); err != nil {
+				log.Printf("manager: file notifier: %s", err)
+			}
+		}
+
+		go func() {
+			m.stopExporters(func(string) bool { return true })
+
+			if started {
+				// process завершается после закрытия уведомлений
+				<-m.processed
+			}
+			m.backfills.Wait()
+			m.scheduler.Wait()
+
+			m.mu.Lock()
+			m.closeReaders(func(string, *journalReader) bool { return true })
+			queues := m.queues
+			m.mu.Unlock()
+
+			// Все прочитанные события передаются в хранилища до остановки
+			for _, q := range queues {
+				q.Stop()
+			}
+
+			m.mu.Lock()
+			m.running = false
+			m.mu.Unlock()
+
+			close(m.stop)
+		}()
+	})
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-m.stop:
+		return nil
+	}
+}
+
+// stopExporters останавливает работающие экспортеры файлов, для которых match возвращает истину,
+// не дожидаясь окончания файлов, и фиксирует позиции, до которых события переданы в хранилища
+func (m *Manager) stopExporters(match func(file string) bool) {
+
+	m.mu.Lock()
+	exporters := make(map[string]*Exporter, len(m.exporters))
+	for file, exporter := range m.exporters {
+		if match(file) {
+			exporters[file] = exporter
+		}
+	}
+	m.mu.Unlock()
+
+	var wg sync.WaitGroup
+
+	for file, exporter := range exporters {
+		wg.Add(1)
+		go func(file string, exporter *Exporter) {
+			defer wg.Done()
+
+			offset, err := exporter.Stop()
+
+			m.mu.Lock()
+			m.commitOffset(file, offset, err)
+			m.mu.Unlock()
+		}(file, exporter)
+	}
+
+	wg.Wait()
 }
 
 func (m *Manager) Running() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	return m.running
 }
 
+// AddStorage добавляет хранилище для событий экспортеров, создаваемых после вызова.
+// События передаются в хранилище через очередь с настройками ManagerOptions.Queue
+func (m *Manager) AddStorage(storage ExporterStorage) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	q := NewQueuedStorage(storage, m.queueOptions)
+
+	m.queues = append(m.queues, q)
+	m.storage = append(m.storage, q)
+}
+
+// Queues возвращает очереди хранилищ событий для контроля отставания
+func (m *Manager) Queues() []*QueuedStorage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]*QueuedStorage(nil), m.queues...)
+}
+
+// Watch добавляет каталог для отслеживания. Повторное добавление каталога не выполняется
 func (m *Manager) Watch(folder string) error {
 
-	if err := m.fileWatcher.AddRecursive(folder); err != nil {
+	m.mu.Lock()
+	watching := m.watching(folder)
+	m.mu.Unlock()
+
+	if watching {
+		return nil
+	}
+
+	if err := m.notifier.Add(folder); err != nil {
 		return err
 	}
 
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.watched = append(m.watched, folder)
+
+	if m.started && m.Backfill {
+		m.backfillFolder(folder)
+	}
+
 	return nil
 }
 
+// Unwatch прекращает отслеживание каталога. Выгрузка файлов каталога останавливается
+// с фиксацией позиций, а открытые читатели файлов закрываются
 func (m *Manager) Unwatch(folder string) error {
 
-	if err := m.fileWatcher.RemoveRecursive(folder); err != nil {
+	if err := m.notifier.Remove(folder); err != nil {
 		return err
 	}
 
+	m.mu.Lock()
+	m.Folder = withoutFolder(m.Folder, folder)
+	m.watched = withoutFolder(m.watched, folder)
+	if cancel, ok := m.backfillCancels[absPath(folder)]; ok {
+		cancel()
+		delete(m.backfillCancels, absPath(folder))
+	}
+	m.mu.Unlock()
+
+	match := func(file string) bool {
+		return inFolder(folder, file)
+	}
+
+	m.stopExporters(match)
+
+	// Читатели, занятые экспортерами, закрываются в exportFile
+	m.mu.Lock()
+	m.closeReaders(func(file string, _ *journalReader) bool {
+		return match(file)
+	})
+	m.mu.Unlock()
+
 	return nil
 }
 
-var ErrLgfNotFound = errors.New("lgf not found")
+// watching проверяет, что файл или каталог находится в отслеживаемом каталоге. Вызывается под m.mu
+func (m *Manager) watching(path string) bool {
 
-func (m *Manager) getPoller() Poller {
-	poller := &LongPoller{
-		Limit:   m.BulkSize,
-		Timeout: m.Timeout,
+	for _, folders := range [][]string{m.Folder, m.watched} {
+		for _, folder := range folders {
+			if inFolder(folder, path) {
+				return true
+			}
+		}
 	}
-	return poller
-}
 
-func (m *Manager) getTurn() {
-	m.queue <- struct{}{}
+	return false
 }
 
-func (p *Manager) waitTurn(ctx context.Context) error {
-	select {
-	case <-ctx.Done():
-		return ctx.Err()
-	case p.queue <- struct{}{}:
-		return nil
-	}
-}
+// inFolder проверяет, что путь path совпадает с каталогом folder или находится в нем
+func inFolder(folder, path string) bool {
 
-func (p *Manager) freeTurn() {
-	<-p.queue
+	folder, path = absPath(folder), absPath(path)
+
+	return path == folder || strings.HasPrefix(path, folder+string(filepath.Separator))
 }
 
-func (m *Manager) removeExporter(ctx context.Context, e watcher.Event) {
+func absPath(path string) string {
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
 
+	return abs
 }
 
-func (m *Manager) process(ctx context.Context) {
+func withoutFolder(folders []string, folder string) []string {
 
-	fileWatcher := m.fileWatcher
+	var result []string
 
-	var err error
-	go func() {
-		err = fileWatcher.Start(m.Ticker)
-		if err != nil {
-			fileWatcher.Close()
+	for _, f := range folders {
+		if absPath(f) != absPath(folder) {
+			result = append(result, f)
 		}
-	}()
+	}
 
-	fileWatcher.Wait()
+	return result
+}
 
-	if err != nil {
-		return
+var ErrLgfNotFound = errors.New("lgf not found")
+
+func (m *Manager) getPoller() Poller {
+	poller := &LongPoller{
+		Limit:     m.BulkSize,
+		Timeout:   m.Timeout,
+		RateLimit: m.RateLimit,
 	}
+	return poller
+}
 
-	m.running = true
+// process обрабатывает уведомления об изменениях файлов до закрытия уведомлений.
+// После отмены контекста менеджера уведомления закрываются, а полученные изменения пропускаются
+func (m *Manager) process() {
+
+	defer close(m.processed)
+
+	notifier := m.notifier
+	done := m.ctx.Done()
 
 	for {
 		select {
-		case <-m.stop:
-			return
-		case e := <-m.fileWatcher.Event:
+		case <-done:
+			done = nil
+			// Close может ожидать, пока уведомления будут вычитаны
+			go func() {
+				if err := notifier.Close(); err != nil {
+					log.Printf("manager: file notifier: %s", err)
+				}
+			}()
+			go m.Stop()
+		case err := <-notifier.Errors():
+			log.Printf("manager: file notifier: %s", err)
+		case e, ok := <-notifier.Events():
+			if !ok {
+				return
+			}
+			if done == nil {
+				continue
+			}
 			switch e.Op {
-			case watcher.Write:
-				m.writeWatcherHook(ctx, e)
-			case watcher.Create:
-				m.createWatcherHook(ctx, e)
-			case watcher.Remove:
-				m.removeWatcherHook(ctx, e)
+			case FileWrite:
+				m.writeWatcherHook(e)
+			case FileCreate:
+				m.createWatcherHook(e)
+			case FileRemove:
+				m.removeWatcherHook(e)
 			}
-
 		}
 	}
 }
 
-func (m *Manager) writeWatcherHook(ctx context.Context, event watcher.Event) {
+func (m *Manager) writeWatcherHook(event FileEvent) {
+	m.scheduler.Schedule(m.ctx, event.Path)
+}
 
-	fileName := event.Path
+// exportFile дочитывает файл журнала с сохраненной позиции и фиксирует новую позицию.
+// Позиция фиксируется после передачи прочитанных событий хранилищам, чтобы при сбое
+// они были прочитаны повторно. При отмене ctx чтение останавливается после текущей партии событий
+func (m *Manager) exportFile(ctx context.Context, fileName string) {
 
-	_, exporterInWork := m.exporters[fileName]
-	if exporterInWork {
+	m.mu.Lock()
+
+	// Уведомление или запуск могли прийти до Unwatch каталога
+	if !m.watching(fileName) {
+		m.mu.Unlock()
+		return
+	}
+
+	offset := m.journals.GetOffset(fileName)
+	jr, err := m.acquireReader(fileName, offset)
+	if err != nil {
+		m.mu.Unlock()
+		log.Print(err)
 		return
 	}
 
+	exporter := NewExporter(jr.reader, m.storage, ExporterConfig{
+		Poller:  m.getPoller(),
+		Context: ctx,
+	})
+	exporter.TZ = m.TZ
+	m.exporters[fileName] = exporter
+	m.mu.Unlock()
+
+	if err := exporter.Start(); err != nil {
+		log.Printf("manager: export %s: %s", fileName, err)
+	}
+
+	offset, err = exporter.Stop()
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	// TODO Подумать над циклом чтения и записи offset
-	offset := m.journals.GetOffset(fileName)
-	exporter, err := createLgpExporter(fileName, offset, m.storage, m.getPoller(), m.TZ)
+	delete(m.exporters, fileName)
+	m.commitOffset(fileName, offset, err)
+
+	jr.busy = false
+	jr.lastUsed = time.Now()
+
+	if !m.watching(fileName) {
+		m.closeReader(fileName)
+	}
+}
+
+// commitOffset фиксирует позицию файла, возвращенную остановкой экспортера.
+// Если хранилища не подтвердили передачу событий, то позиция не меняется
+// и события будут прочитаны повторно. Вызывается под m.mu
+func (m *Manager) commitOffset(file string, offset int64, err error) {
 
 	if err != nil {
-		log.Print(err)
+		log.Printf("manager: flush %s: %s, offset %d not committed", file, err, offset)
+		return
+	}
+
+	m.journals.SetOffset(file, offset)
+}
+
+// backfillFolder запускает выгрузку существующих файлов журналов каталога и его подкаталогов.
+// Вызывается под m.mu
+func (m *Manager) backfillFolder(folder string) {
+
+	if m.ctx.Err() != nil {
 		return
 	}
 
-	go func(key string) {
-		err := m.waitTurn(ctx)
+	ctx, cancel := context.WithCancel(m.ctx)
+	if prev, ok := m.backfillCancels[absPath(folder)]; ok {
+		prev()
+	}
+	m.backfillCancels[absPath(folder)] = cancel
+
+	m.backfills.Add(1)
+
+	go func() {
+		defer m.backfills.Done()
+
+		journals, err := journalFolders(folder)
 		if err != nil {
+			log.Printf("manager: backfill %s: %s", folder, err)
+			return
+		}
+
+		for _, files := range journals {
+			m.backfills.Add(1)
+			go func(files []string) {
+				defer m.backfills.Done()
+				m.backfillJournal(ctx, files)
+			}(files)
+		}
+	}()
+}
+
+// backfillJournal выгружает файлы журнала от старых к новым.
+// Последний файл передается в обычную обработку, т.к. в него продолжается запись
+func (m *Manager) backfillJournal(ctx context.Context, files []string) {
+
+	last := len(files) - 1
+
+	for _, file := range files[:last] {
+		select {
+		case <-ctx.Done():
 			return
+		case <-m.scheduler.Backfill(ctx, file, m.backfillSlots):
 		}
 
-		m.getTurn()
-		exporter.Start()
+		// В старые файлы запись не ведется, читатель больше не нужен
 		m.mu.Lock()
-		defer m.mu.Unlock()
-		delete(m.exporters, key)
-		m.journals.SetOffset(fileName, exporter.eventReader.Offset())
-		m.freeTurn()
+		m.closeReader(file)
+		m.mu.Unlock()
+	}
 
-	}(fileName)
+	if ctx.Err() == nil {
+		m.scheduler.Schedule(ctx, files[last])
+	}
 }
 
-func (m *Manager) createWatcherHook(ctx context.Context, e watcher.Event) {
+// journalFolders возвращает файлы журналов регистрации каталога и его подкаталогов,
+// сгруппированные по каталогам журналов и упорядоченные от старых к новым
+func journalFolders(folder string) ([][]string, error) {
+
+	folder, err := filepath.Abs(folder)
+	if err != nil {
+		return nil, err
+	}
+
+	var journals [][]string
+
+	err = filepath.Walk(folder, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+
+		files, err := JournalFiles(path)
+		if err != nil {
+			return err
+		}
+		if len(files) > 0 {
+			journals = append(journals, files)
+		}
+		return nil
+	})
+
+	return journals, err
+}
 
+// journalReader открытый читатель файла журнала.
+// Сохраняется между проходами, чтобы не читать заново файл lgf
+type journalReader struct {
+	reader   *LgpReader
+	busy     bool      // Читатель используется экспортером
+	lastUsed time.Time // Время окончания последнего прохода
 }
 
-func (m *Manager) removeWatcherHook(ctx context.Context, e watcher.Event) {
+// acquireReader возвращает открытый читатель файла, установленный на позицию offset.
+// Вызывается под m.mu
+func (m *Manager) acquireReader(file string, offset int64) (*journalReader, error) {
+
+	if jr, ok := m.readers[file]; ok {
+		// Парсер пересоздается, т.к. прошлый проход мог закончиться на недописанном событии
+		if _, err := jr.reader.reset(offset); err == nil {
+			jr.busy = true
+			return jr, nil
+		}
+		m.closeReader(file)
+	}
+
+	reader, err := openLgpReader(file, offset, m.TZ)
+	if err != nil {
+		return nil, err
+	}
+
+	jr := &journalReader{reader: reader, busy: true}
+	m.readers[file] = jr
+
+	return jr, nil
+}
+
+// closeReader закрывает неиспользуемый читатель файла. Вызывается под m.mu
+func (m *Manager) closeReader(file string) {
+
+	jr, ok := m.readers[file]
+	if !ok || jr.busy {
+		return
+	}
+
+	if err := jr.reader.Close(); err != nil {
+		log.Printf("manager: close %s: %s", file, err)
+	}
+	delete(m.readers, file)
+}
+
+// closeReaders закрывает неиспользуемые читатели, для которых idle возвращает истину.
+// Вызывается под m.mu
+func (m *Manager) closeReaders(idle func(file string, jr *journalReader) bool) {
+	for file, jr := range m.readers {
+		if idle(file, jr) {
+			m.closeReader(file)
+		}
+	}
+}
+
+// checkIdle периодически закрывает читатели файлов, в которые давно не было записи
+func (m *Manager) checkIdle(ctx context.Context, frequency time.Duration) {
+
+	ticker := time.NewTicker(frequency)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			m.mu.Lock()
+			m.closeReaders(func(_ string, jr *journalReader) bool {
+				return now.Sub(jr.lastUsed) >= frequency
+			})
+			m.mu.Unlock()
+		}
+	}
+}
+
+func (m *Manager) createWatcherHook(e FileEvent) {
+
+}
+
+func (m *Manager) removeWatcherHook(e FileEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
+	m.closeReader(e.Path)
 }
@@ -2,6 +2,11 @@ package eventlog
 
 import (
 	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 )
@@ -36,11 +41,656 @@ func TestManager_Watch(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			m := NewManager(tt.args.ctx, tt.options)
+			if err := m.Start(); err != nil {
+				t.Fatal(err)
+			}
 			if err := m.Watch(tt.args.folder); (err != nil) != tt.wantErr {
 				t.Errorf("Watch() error = %v, wantErr %v", err, tt.wantErr)
 			}
 
+			m.Stop()
 			m.Wait()
 		})
 	}
 }
+
+type syncStorage struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (s *syncStorage) Push(event Event) {
+	s.mu.Lock()
+	s.events = append(s.events, event)
+	s.mu.Unlock()
+}
+
+func (s *syncStorage) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.events)
+}
+
+// copyTestJournal копирует тестовый журнал во временный каталог.
+// Из файла журнала копируются первые size байт, остальные возвращаются для дописывания
+func copyTestJournal(t *testing.T, size int) (string, string, []byte) {
+
+	dir := t.TempDir()
+
+	lgf, err := ioutil.ReadFile(filepath.Join("tests", lgfFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, lgfFileName), lgf, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	lgp, err := ioutil.ReadFile(filepath.Join("tests", "20210108100000.lgp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size < 0 || size > len(lgp) {
+		size = len(lgp)
+	}
+
+	file := filepath.Join(dir, "20210108100000.lgp")
+	if err := ioutil.WriteFile(file, lgp[:size], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	return dir, file, lgp[size:]
+}
+
+func appendFile(t *testing.T, file string, data []byte) {
+
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestManager_Lifecycle(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		notifier func() FileNotifier
+	}{
+		{"default", func() FileNotifier { return nil }},
+		{"polling", func() FileNotifier { return NewPollingNotifier(10 * time.Millisecond) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			dir, file, tail := copyTestJournal(t, 1000)
+
+			journal := NewInMemoryJournal()
+			storage := &syncStorage{}
+
+			m := NewManager(context.Background(), ManagerOptions{
+				PoolSize:       2,
+				BulkSize:       500,
+				JournalStorage: journal,
+				Notifier:       tt.notifier(),
+			})
+			m.Ticker = 10 * time.Millisecond
+			m.AddStorage(storage)
+
+			if m.Running() {
+				t.Fatal("manager running before Start")
+			}
+
+			if err := m.Start(); err != nil {
+				t.Fatal(err)
+			}
+			if err := m.Start(); err != ErrManagerStarted {
+				t.Errorf("second Start() error = %v", err)
+			}
+			if !m.Running() {
+				t.Fatal("manager not running after Start")
+			}
+
+			if err := m.Watch(dir); err != nil {
+				t.Fatal(err)
+			}
+
+			// Дописывание в файл приводит к его чтению
+			time.Sleep(50 * time.Millisecond)
+			appendFile(t, file, tail[:len(tail)/2])
+			time.Sleep(20 * time.Millisecond)
+			appendFile(t, file, tail[len(tail)/2:])
+
+			abs, _ := filepath.Abs(file)
+			size := int64(1000 + len(tail))
+
+			deadline := time.Now().Add(10 * time.Second)
+			for journal.GetOffset(abs) != size && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			if err := m.Shutdown(ctx); err != nil {
+				t.Fatal(err)
+			}
+			m.Wait()
+
+			if m.Running() {
+				t.Error("manager running after Shutdown")
+			}
+
+			if offset := journal.GetOffset(abs); offset != size {
+				t.Errorf("committed offset = %d, want %d", offset, size)
+			}
+
+			if storage.Len() == 0 {
+				t.Error("no events exported")
+			}
+
+			if err := m.Start(); err != ErrManagerStopped {
+				t.Errorf("Start() after Shutdown error = %v", err)
+			}
+		})
+	}
+}
+
+func TestManager_ContextCancel(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	m := NewManager(ctx, ManagerOptions{PoolSize: 1})
+	m.Ticker = 10 * time.Millisecond
+
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	cancel()
+
+	select {
+	case <-m.stop:
+	case <-time.After(5 * time.Second):
+		t.Fatal("manager not stopped after context cancel")
+	}
+}
+
+func TestManager_StartError(t *testing.T) {
+
+	m := NewManager(context.Background(), ManagerOptions{
+		PoolSize: 1,
+		Notifier: NewPollingNotifier(time.Second),
+	})
+	m.Ticker = 0
+
+	if err := m.Start(); err == nil {
+		t.Error("expected file notifier start error")
+	}
+
+	m.Stop()
+}
+
+func TestManager_Options(t *testing.T) {
+
+	dir, file, tail := copyTestJournal(t, 1000)
+
+	journal := NewInMemoryJournal()
+	storage := &syncStorage{}
+
+	m := NewManager(context.Background(), ManagerOptions{
+		Folder:             []string{dir},
+		PoolSize:           1,
+		Timeout:            time.Second,
+		IdleCheckFrequency: 50 * time.Millisecond,
+		JournalStorage:     journal,
+		Exporters:          []ExporterStorage{storage},
+		Notifier:           NewPollingNotifier(10 * time.Millisecond),
+	})
+	m.Ticker = 10 * time.Millisecond
+	defer m.Stop()
+
+	if m.BulkSize != defaultBulkSize {
+		t.Errorf("BulkSize = %d, want default %d", m.BulkSize, defaultBulkSize)
+	}
+
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Каталог из настроек отслеживается без вызова Watch
+	time.Sleep(50 * time.Millisecond)
+	appendFile(t, file, tail)
+
+	abs, _ := filepath.Abs(file)
+	size := int64(1000 + len(tail))
+
+	deadline := time.Now().Add(10 * time.Second)
+	for journal.GetOffset(abs) != size && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if offset := journal.GetOffset(abs); offset != size {
+		t.Fatalf("committed offset = %d, want %d", offset, size)
+	}
+
+	// События передаются в хранилище через очередь
+	queues := m.Queues()
+	if len(queues) != 1 || queues[0].Storage() != storage {
+		t.Fatalf("queues = %v, want one queue of options exporter", queues)
+	}
+	if err := queues[0].Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if storage.Len() == 0 {
+		t.Error("no events exported to options exporters")
+	}
+
+	// Читатель файла без записи закрывается проверкой простоя
+	deadline = time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		m.mu.Lock()
+		n := len(m.readers)
+		m.mu.Unlock()
+		if n == 0 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("idle reader not closed")
+}
+
+func TestManager_Backfill(t *testing.T) {
+
+	lgp, err := ioutil.ReadFile(filepath.Join("tests", "20210108100000.lgp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lgf, err := ioutil.ReadFile(filepath.Join("tests", lgfFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	logDir := filepath.Join(dir, "ib", "1Cv8Log")
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string][]byte{
+		lgfFileName:          lgf,
+		"20210108100000.lgp": lgp,
+		"20210108110000.lgp": lgp[:1000],
+	}
+	for name, data := range files {
+		if err := ioutil.WriteFile(filepath.Join(logDir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	journal := NewInMemoryJournal()
+	storage := &syncStorage{}
+
+	m := NewManager(context.Background(), ManagerOptions{
+		Folder:           []string{dir},
+		PoolSize:         1,
+		BulkSize:         500,
+		JournalStorage:   journal,
+		Exporters:        []ExporterStorage{storage},
+		Notifier:         NewPollingNotifier(10 * time.Millisecond),
+		Backfill:         true,
+		BackfillPoolSize: 2,
+	})
+	m.Ticker = 10 * time.Millisecond
+
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	old, _ := filepath.Abs(filepath.Join(logDir, "20210108100000.lgp"))
+	last, _ := filepath.Abs(filepath.Join(logDir, "20210108110000.lgp"))
+
+	// Последний файл обрывается на недописанном событии, целые события заканчиваются раньше
+	const lastComplete = 984
+
+	// Файлы выгружаются без записи в них
+	deadline := time.Now().Add(10 * time.Second)
+	for (journal.GetOffset(old) != int64(len(lgp)) || journal.GetOffset(last) != lastComplete) &&
+		time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if offset := journal.GetOffset(old); offset != int64(len(lgp)) {
+		t.Errorf("old file offset = %d, want %d", offset, len(lgp))
+	}
+	if offset := journal.GetOffset(last); offset != lastComplete {
+		t.Errorf("last file offset = %d, want %d", offset, lastComplete)
+	}
+
+	// Последний файл продолжает обрабатываться в обычном режиме
+	time.Sleep(50 * time.Millisecond)
+	appendFile(t, last, lgp[1000:])
+
+	deadline = time.Now().Add(10 * time.Second)
+	for journal.GetOffset(last) != int64(len(lgp)) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if offset := journal.GetOffset(last); offset != int64(len(lgp)) {
+		t.Errorf("last file offset after write = %d, want %d", offset, len(lgp))
+	}
+
+	m.mu.Lock()
+	_, oldOpen := m.readers[old]
+	m.mu.Unlock()
+	if oldOpen {
+		t.Error("reader of backfilled file left open")
+	}
+
+	m.Stop()
+
+	if storage.Len() == 0 {
+		t.Error("no events exported")
+	}
+}
+
+// deliveryStorage медленное хранилище, запоминающее конец последнего полученного события
+type deliveryStorage struct {
+	delay time.Duration
+
+	mu  sync.Mutex
+	end map[string]int64
+}
+
+func (s *deliveryStorage) Push(event Event) {
+
+	time.Sleep(s.delay)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.end == nil {
+		s.end = make(map[string]int64)
+	}
+	s.end[event.JournalFile] = event.Offset + event.Size
+}
+
+func (s *deliveryStorage) End(file string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.end[file]
+}
+
+// deliveryJournal проверяет, что позиция фиксируется только после передачи событий в хранилище
+type deliveryJournal struct {
+	*InMemoryJournal
+	t       *testing.T
+	storage *deliveryStorage
+}
+
+func (j deliveryJournal) SetOffset(file string, offset int64) {
+
+	if end := j.storage.End(file); end < offset {
+		j.t.Errorf("offset %d committed before delivery, delivered up to %d", offset, end)
+	}
+
+	j.InMemoryJournal.SetOffset(file, offset)
+}
+
+func TestManager_CommitAfterDelivery(t *testing.T) {
+
+	dir, file, _ := copyTestJournal(t, 100000)
+
+	storage := &deliveryStorage{delay: 50 * time.Microsecond}
+	journal := deliveryJournal{InMemoryJournal: NewInMemoryJournal(), t: t, storage: storage}
+
+	m := NewManager(context.Background(), ManagerOptions{
+		Folder:         []string{dir},
+		PoolSize:       1,
+		JournalStorage: journal,
+		Exporters:      []ExporterStorage{storage},
+		Notifier:       NewPollingNotifier(10 * time.Millisecond),
+		Backfill:       true,
+	})
+
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	abs, _ := filepath.Abs(file)
+
+	deadline := time.Now().Add(10 * time.Second)
+	for journal.GetOffset(abs) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	m.Stop()
+
+	if journal.GetOffset(abs) == 0 {
+		t.Error("offset not committed")
+	}
+}
+
+func TestManager_ShutdownStopsExporters(t *testing.T) {
+
+	dir, file, _ := copyTestJournal(t, -1)
+
+	storage := &deliveryStorage{delay: time.Millisecond}
+	journal := deliveryJournal{InMemoryJournal: NewInMemoryJournal(), t: t, storage: storage}
+
+	m := NewManager(context.Background(), ManagerOptions{
+		Folder:         []string{dir},
+		PoolSize:       1,
+		BulkSize:       100,
+		JournalStorage: journal,
+		Exporters:      []ExporterStorage{storage},
+		Notifier:       NewPollingNotifier(10 * time.Millisecond),
+		Backfill:       true,
+		Queue:          QueueOptions{Size: 10},
+	})
+
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	abs, _ := filepath.Abs(file)
+
+	deadline := time.Now().Add(10 * time.Second)
+	for storage.End(abs) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Экспортер не дочитывает файл, а останавливается после текущей партии
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := m.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	offset := journal.GetOffset(abs)
+	if offset == 0 || offset >= info.Size() {
+		t.Errorf("committed offset = %d, want position inside file of %d bytes", offset, info.Size())
+	}
+	if end := storage.End(abs); end != offset {
+		t.Errorf("committed offset = %d, delivered up to %d", offset, end)
+	}
+}
+
+// failingStorage хранилище, не подтверждающее передачу событий
+type failingStorage struct {
+	syncStorage
+}
+
+func (s *failingStorage) Flush() error {
+	return errors.New("storage not available")
+}
+
+func TestManager_NoCommitOnFlushError(t *testing.T) {
+
+	dir, file, _ := copyTestJournal(t, 100000)
+
+	journal := NewInMemoryJournal()
+	storage := &failingStorage{}
+
+	m := NewManager(context.Background(), ManagerOptions{
+		Folder:         []string{dir},
+		PoolSize:       1,
+		JournalStorage: journal,
+		Exporters:      []ExporterStorage{storage},
+		Notifier:       NewPollingNotifier(10 * time.Millisecond),
+		Backfill:       true,
+	})
+
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for storage.Len() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	m.Stop()
+
+	if storage.Len() == 0 {
+		t.Fatal("no events exported")
+	}
+
+	abs, _ := filepath.Abs(file)
+	if offset := journal.GetOffset(abs); offset != 0 {
+		t.Errorf("committed offset = %d after flush error, want 0", offset)
+	}
+}
+
+func TestManager_CommitAfterSpill(t *testing.T) {
+
+	dir, file, _ := copyTestJournal(t, 100000)
+	spillDir := t.TempDir()
+
+	storage := &deliveryStorage{delay: 50 * time.Microsecond}
+	journal := deliveryJournal{InMemoryJournal: NewInMemoryJournal(), t: t, storage: storage}
+
+	m := NewManager(context.Background(), ManagerOptions{
+		Folder:         []string{dir},
+		PoolSize:       1,
+		JournalStorage: journal,
+		Exporters:      []ExporterStorage{storage},
+		Notifier:       NewPollingNotifier(10 * time.Millisecond),
+		Backfill:       true,
+		Queue:          QueueOptions{Size: 5, Overflow: OverflowSpill, SpillDir: spillDir},
+	})
+
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	abs, _ := filepath.Abs(file)
+
+	deadline := time.Now().Add(10 * time.Second)
+	for journal.GetOffset(abs) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	m.Stop()
+
+	if journal.GetOffset(abs) == 0 {
+		t.Error("offset not committed")
+	}
+
+	files, err := ioutil.ReadDir(spillDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Errorf("spill files left: %d", len(files))
+	}
+}
+
+func TestManager_Unwatch(t *testing.T) {
+
+	dir, file, _ := copyTestJournal(t, -1)
+
+	storage := &deliveryStorage{delay: time.Millisecond}
+	journal := deliveryJournal{InMemoryJournal: NewInMemoryJournal(), t: t, storage: storage}
+
+	m := NewManager(context.Background(), ManagerOptions{
+		PoolSize:       1,
+		BulkSize:       100,
+		JournalStorage: journal,
+		Exporters:      []ExporterStorage{storage},
+		Notifier:       NewPollingNotifier(10 * time.Millisecond),
+		Backfill:       true,
+		Queue:          QueueOptions{Size: 10},
+	})
+	defer m.Stop()
+
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := m.Watch(dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	abs, _ := filepath.Abs(file)
+
+	deadline := time.Now().Add(10 * time.Second)
+	for storage.End(abs) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := m.Unwatch(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	// Выгрузка остановлена с фиксацией позиции, не дочитав файл
+	deadline = time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		m.mu.Lock()
+		n := len(m.exporters) + len(m.readers)
+		m.mu.Unlock()
+		if n == 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	m.mu.Lock()
+	watched, exporters, readers := len(m.watched), len(m.exporters), len(m.readers)
+	m.mu.Unlock()
+
+	if watched != 0 || exporters != 0 || readers != 0 {
+		t.Fatalf("after Unwatch watched = %d, exporters = %d, readers = %d", watched, exporters, readers)
+	}
+
+	info, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if offset := journal.GetOffset(abs); offset == 0 || offset >= info.Size() {
+		t.Errorf("committed offset = %d, want position inside file of %d bytes", offset, info.Size())
+	}
+
+	// Каталог можно отслеживать снова
+	if err := m.Watch(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	m.mu.Lock()
+	watched = len(m.watched)
+	m.mu.Unlock()
+
+	if watched != 1 {
+		t.Errorf("watched folders = %d, want 1", watched)
+	}
+}
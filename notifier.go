@@ -0,0 +1,340 @@
+package eventlog
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/radovskyb/watcher"
+	"github.com/xelaj/go-dry"
+)
+
+// FileOp вид изменения файла журнала регистрации
+type FileOp int
+
+const (
+	FileCreate FileOp = iota + 1
+	FileWrite
+	FileRemove
+)
+
+// FileEvent изменение файла журнала регистрации
+type FileEvent struct {
+	Op   FileOp
+	Path string // Полный путь к файлу
+}
+
+// FileNotifier уведомляет об изменениях файлов журналов регистрации в отслеживаемых каталогах.
+//
+// Каталоги отслеживаются рекурсивно, уведомления передаются только для файлов .lgp и .lgd.
+// После Close канал Events закрывается
+type FileNotifier interface {
+	Add(folder string) error
+	Remove(folder string) error
+	Start() error
+	Close() error
+	Events() <-chan FileEvent
+	Errors() <-chan error
+}
+
+type intervalSetter interface {
+	SetInterval(interval time.Duration)
+}
+
+var journalFileExt = []string{".lgp", ".lgd"}
+
+func isJournalFile(path string) bool {
+	return dry.StringListContains(journalFileExt, filepath.Ext(path))
+}
+
+// NewFileNotifier создает уведомитель для текущей платформы.
+// Если уведомления операционной системы недоступны, то используется периодический опрос каталогов
+func NewFileNotifier(interval time.Duration) FileNotifier {
+	return newDefaultNotifier(interval)
+}
+
+var _ FileNotifier = (*PollingNotifier)(nil)
+
+// PollingNotifier обнаруживает изменения, периодически сравнивая размеры и время изменения файлов
+type PollingNotifier struct {
+	interval time.Duration
+	watcher  *watcher.Watcher
+	events   chan FileEvent
+	errors   chan error
+}
+
+func NewPollingNotifier(interval time.Duration) *PollingNotifier {
+
+	w := watcher.New()
+	w.AddFilterHook(filterFiles)
+	w.FilterOps(watcher.Create, watcher.Write, watcher.Remove)
+
+	return &PollingNotifier{
+		interval: interval,
+		watcher:  w,
+		events:   make(chan FileEvent),
+		errors:   make(chan error),
+	}
+}
+
+// SetInterval устанавливает период опроса. Действует при вызове Start
+func (n *PollingNotifier) SetInterval(interval time.Duration) {
+	n.interval = interval
+}
+
+func (n *PollingNotifier) Add(folder string) error {
+	return n.watcher.AddRecursive(folder)
+}
+
+func (n *PollingNotifier) Remove(folder string) error {
+	return n.watcher.RemoveRecursive(folder)
+}
+
+// Start запускает опрос каталогов и возвращает управление после его начала
+func (n *PollingNotifier) Start() error {
+
+	errc := make(chan error, 1)
+	started := make(chan struct{})
+
+	go func() {
+		errc <- n.watcher.Start(n.interval)
+	}()
+
+	go func() {
+		n.watcher.Wait()
+		close(started)
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-started:
+	}
+
+	go n.forward()
+
+	return nil
+}
+
+func (n *PollingNotifier) forward() {
+
+	defer close(n.events)
+
+	ops := map[watcher.Op]FileOp{
+		watcher.Create: FileCreate,
+		watcher.Write:  FileWrite,
+		watcher.Remove: FileRemove,
+	}
+
+	for {
+		select {
+		case <-n.watcher.Closed:
+			return
+		case err := <-n.watcher.Error:
+			select {
+			case n.errors <- err:
+			case <-n.watcher.Closed:
+				return
+			}
+		case e := <-n.watcher.Event:
+			op, ok := ops[e.Op]
+			if !ok || e.IsDir() {
+				continue
+			}
+			select {
+			case n.events <- FileEvent{Op: op, Path: e.Path}:
+			case <-n.watcher.Closed:
+				return
+			}
+		}
+	}
+}
+
+// Close останавливает опрос. Может ожидать окончания текущего периода опроса
+func (n *PollingNotifier) Close() error {
+	n.watcher.Close()
+	return nil
+}
+
+func (n *PollingNotifier) Events() <-chan FileEvent {
+	return n.events
+}
+
+func (n *PollingNotifier) Errors() <-chan error {
+	return n.errors
+}
+
+func extFilterHook(ext ...string) watcher.FilterFileHookFunc {
+	return func(info os.FileInfo, fullPath string) error {
+
+		if !info.IsDir() && dry.StringListContains(ext, filepath.Ext(info.Name())) {
+			return nil
+		}
+
+		// No match.
+		return watcher.ErrSkip
+	}
+}
+
+var filterFiles = extFilterHook(journalFileExt...)
+
+var _ FileNotifier = (*fallbackNotifier)(nil)
+
+// fallbackNotifier получает уведомления от primary, а каталоги, которые primary
+// не смог добавить (например, исчерпан лимит отслеживаний inotify), опрашивает периодически
+type fallbackNotifier struct {
+	primary FileNotifier
+	polling *PollingNotifier
+	events  chan FileEvent
+	errors  chan error
+
+	mu      sync.Mutex
+	polled  map[string]struct{} // Каталоги, отслеживаемые опросом
+	started bool
+	closed  chan struct{}
+}
+
+func newFallbackNotifier(primary FileNotifier, interval time.Duration) *fallbackNotifier {
+	return &fallbackNotifier{
+		primary: primary,
+		polling: NewPollingNotifier(interval),
+		events:  make(chan FileEvent),
+		errors:  make(chan error),
+		polled:  make(map[string]struct{}),
+		closed:  make(chan struct{}),
+	}
+}
+
+func (n *fallbackNotifier) SetInterval(interval time.Duration) {
+	n.polling.SetInterval(interval)
+}
+
+func (n *fallbackNotifier) Add(folder string) error {
+
+	err := n.primary.Add(folder)
+	if err == nil {
+		return nil
+	}
+
+	log.Printf("file notifier: <%s> polling is used: %s", folder, err)
+
+	// Подкаталоги, добавленные до ошибки, отслеживаются опросом
+	_ = n.primary.Remove(folder)
+
+	if err := n.polling.Add(folder); err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	n.polled[folder] = struct{}{}
+	n.mu.Unlock()
+
+	return nil
+}
+
+func (n *fallbackNotifier) Remove(folder string) error {
+
+	n.mu.Lock()
+	_, polled := n.polled[folder]
+	delete(n.polled, folder)
+	n.mu.Unlock()
+
+	if polled {
+		return n.polling.Remove(folder)
+	}
+
+	return n.primary.Remove(folder)
+}
+
+func (n *fallbackNotifier) Start() error {
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.started {
+		return nil
+	}
+
+	if err := n.primary.Start(); err != nil {
+		return err
+	}
+	if err := n.polling.Start(); err != nil {
+		return err
+	}
+
+	n.started = true
+
+	var wg sync.WaitGroup
+	for _, source := range []FileNotifier{n.primary, n.polling} {
+		wg.Add(1)
+		go func(source FileNotifier) {
+			defer wg.Done()
+			n.forward(source)
+		}(source)
+	}
+
+	go func() {
+		wg.Wait()
+		close(n.events)
+	}()
+
+	return nil
+}
+
+func (n *fallbackNotifier) forward(source FileNotifier) {
+
+	for {
+		select {
+		case err := <-source.Errors():
+			select {
+			case n.errors <- err:
+			case <-n.closed:
+				return
+			}
+		case e, ok := <-source.Events():
+			if !ok {
+				return
+			}
+			select {
+			case n.events <- e:
+			case <-n.closed:
+				return
+			}
+		}
+	}
+}
+
+func (n *fallbackNotifier) Close() error {
+
+	n.mu.Lock()
+	select {
+	case <-n.closed:
+		n.mu.Unlock()
+		return nil
+	default:
+	}
+	close(n.closed)
+	started := n.started
+	n.mu.Unlock()
+
+	err := n.primary.Close()
+	if pollErr := n.polling.Close(); err == nil {
+		err = pollErr
+	}
+
+	if !started {
+		close(n.events)
+	}
+
+	return err
+}
+
+func (n *fallbackNotifier) Events() <-chan FileEvent {
+	return n.events
+}
+
+func (n *fallbackNotifier) Errors() <-chan error {
+	return n.errors
+}
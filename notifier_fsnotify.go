@@ -0,0 +1,239 @@
+package eventlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+var _ FileNotifier = (*FsNotifier)(nil)
+
+// FsNotifier получает изменения файлов от операционной системы (inotify в Linux).
+// Новые подкаталоги отслеживаемых каталогов добавляются автоматически
+type FsNotifier struct {
+	watcher *fsnotify.Watcher
+	events  chan FileEvent
+	errors  chan error
+
+	mu      sync.Mutex
+	folders map[string]struct{} // Отслеживаемые каталоги, включая подкаталоги
+	started bool
+	closed  chan struct{}
+}
+
+func NewFsNotifier() (*FsNotifier, error) {
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	return &FsNotifier{
+		watcher: w,
+		events:  make(chan FileEvent),
+		errors:  make(chan error),
+		folders: make(map[string]struct{}),
+		closed:  make(chan struct{}),
+	}, nil
+}
+
+// Add начинает отслеживание каталога и всех его подкаталогов
+func (n *FsNotifier) Add(folder string) error {
+
+	folder, err := filepath.Abs(folder)
+	if err != nil {
+		return err
+	}
+
+	return filepath.Walk(folder, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		return n.addDir(path)
+	})
+}
+
+func (n *FsNotifier) addDir(dir string) error {
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if _, ok := n.folders[dir]; ok {
+		return nil
+	}
+
+	if err := n.watcher.Add(dir); err != nil {
+		return err
+	}
+
+	n.folders[dir] = struct{}{}
+
+	return nil
+}
+
+// Remove прекращает отслеживание каталога и всех его подкаталогов
+func (n *FsNotifier) Remove(folder string) error {
+
+	folder, err := filepath.Abs(folder)
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for dir := range n.folders {
+		if dir != folder && !strings.HasPrefix(dir, folder+string(filepath.Separator)) {
+			continue
+		}
+
+		// Каталог мог быть уже удален вместе с отслеживанием
+		_ = n.watcher.Remove(dir)
+		delete(n.folders, dir)
+	}
+
+	return nil
+}
+
+func (n *FsNotifier) Start() error {
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if !n.started {
+		n.started = true
+		go n.forward()
+	}
+
+	return nil
+}
+
+func (n *FsNotifier) forward() {
+
+	defer close(n.events)
+
+	for {
+		select {
+		case err, ok := <-n.watcher.Errors:
+			if !ok {
+				return
+			}
+			select {
+			case n.errors <- err:
+			case <-n.closed:
+				return
+			}
+		case e, ok := <-n.watcher.Events:
+			if !ok {
+				return
+			}
+			for _, event := range n.convert(e) {
+				select {
+				case n.events <- event:
+				case <-n.closed:
+					return
+				}
+			}
+		}
+	}
+}
+
+// convert переводит уведомление в изменения файлов журнала.
+// Для нового каталога начинается его отслеживание и передаются уже созданные в нем файлы
+func (n *FsNotifier) convert(e fsnotify.Event) []FileEvent {
+
+	switch {
+	case e.Op&fsnotify.Create != 0:
+
+		info, err := os.Stat(e.Name)
+		if err != nil {
+			return nil
+		}
+
+		if !info.IsDir() {
+			if isJournalFile(e.Name) {
+				return []FileEvent{{Op: FileCreate, Path: e.Name}}
+			}
+			return nil
+		}
+
+		var events []FileEvent
+
+		_ = filepath.Walk(e.Name, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if info.IsDir() {
+				if err := n.addDir(path); err != nil {
+					events = nil
+					return err
+				}
+				return nil
+			}
+			if isJournalFile(path) {
+				events = append(events, FileEvent{Op: FileCreate, Path: path})
+			}
+			return nil
+		})
+
+		return events
+
+	case e.Op&fsnotify.Write != 0:
+		if isJournalFile(e.Name) {
+			return []FileEvent{{Op: FileWrite, Path: e.Name}}
+		}
+
+	case e.Op&(fsnotify.Remove|fsnotify.Rename) != 0:
+
+		n.mu.Lock()
+		_, isDir := n.folders[e.Name]
+		n.mu.Unlock()
+
+		if isDir {
+			_ = n.Remove(e.Name)
+			return nil
+		}
+
+		if isJournalFile(e.Name) {
+			return []FileEvent{{Op: FileRemove, Path: e.Name}}
+		}
+	}
+
+	return nil
+}
+
+func (n *FsNotifier) Close() error {
+
+	n.mu.Lock()
+	select {
+	case <-n.closed:
+		n.mu.Unlock()
+		return nil
+	default:
+	}
+	close(n.closed)
+	started := n.started
+	n.mu.Unlock()
+
+	err := n.watcher.Close()
+
+	if !started {
+		close(n.events)
+	}
+
+	return err
+}
+
+func (n *FsNotifier) Events() <-chan FileEvent {
+	return n.events
+}
+
+func (n *FsNotifier) Errors() <-chan error {
+	return n.errors
+}
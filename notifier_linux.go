@@ -0,0 +1,19 @@
+package eventlog
+
+import (
+	"log"
+	"time"
+)
+
+// newDefaultNotifier в Linux использует inotify, при ошибке — опрос каталогов.
+// Каталоги, которые не удалось добавить в inotify, также опрашиваются
+func newDefaultNotifier(interval time.Duration) FileNotifier {
+
+	n, err := NewFsNotifier()
+	if err != nil {
+		log.Printf("file notifier: inotify not available, polling is used: %s", err)
+		return NewPollingNotifier(interval)
+	}
+
+	return newFallbackNotifier(n, interval)
+}
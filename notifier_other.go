@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package eventlog
+
+import "time"
+
+func newDefaultNotifier(interval time.Duration) FileNotifier {
+	return NewPollingNotifier(interval)
+}
@@ -0,0 +1,116 @@
+package eventlog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// limitedNotifier не может добавить каталог, как inotify при исчерпании лимита отслеживаний
+type limitedNotifier struct {
+	*PollingNotifier
+}
+
+func (n *limitedNotifier) Add(folder string) error {
+	return syscall.ENOSPC
+}
+
+// waitFileEvent ожидает изменение файла, пропуская остальные уведомления
+func waitFileEvent(t *testing.T, n FileNotifier, op FileOp, path string) {
+
+	deadline := time.After(5 * time.Second)
+
+	for {
+		select {
+		case e, ok := <-n.Events():
+			if !ok {
+				t.Fatalf("events closed while waiting for %d %s", op, path)
+			}
+			if e.Op == op && e.Path == path {
+				return
+			}
+		case <-deadline:
+			t.Fatalf("no event %d for %s", op, path)
+		}
+	}
+}
+
+func TestFileNotifier(t *testing.T) {
+
+	fsNotifier, err := NewFsNotifier()
+	if err != nil {
+		t.Skipf("fsnotify not available: %s", err)
+	}
+
+	tests := []struct {
+		name     string
+		notifier FileNotifier
+	}{
+		{"fsnotify", fsNotifier},
+		{"polling", NewPollingNotifier(10 * time.Millisecond)},
+		{"fallback", newFallbackNotifier(&limitedNotifier{NewPollingNotifier(10 * time.Millisecond)}, 10*time.Millisecond)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			n := tt.notifier
+			dir := t.TempDir()
+
+			if err := n.Add(dir); err != nil {
+				t.Fatal(err)
+			}
+			if err := n.Start(); err != nil {
+				t.Fatal(err)
+			}
+
+			// Журнал новой информационной базы
+			logDir := filepath.Join(dir, "ib", "1Cv8Log")
+			if err := os.MkdirAll(logDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			file := filepath.Join(logDir, "20210108100000.lgp")
+			if err := ioutil.WriteFile(file, []byte("header\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(filepath.Join(logDir, "readme.txt"), []byte("skip"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			waitFileEvent(t, n, FileCreate, file)
+
+			// Период опроса должен смениться, чтобы изменение было замечено
+			time.Sleep(20 * time.Millisecond)
+			appendFile(t, file, []byte("{event}\n"))
+
+			waitFileEvent(t, n, FileWrite, file)
+
+			if err := os.Remove(file); err != nil {
+				t.Fatal(err)
+			}
+
+			waitFileEvent(t, n, FileRemove, file)
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for range n.Events() {
+				}
+			}()
+
+			if err := n.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("events not closed after Close")
+			}
+		})
+	}
+}
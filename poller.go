@@ -1,19 +1,27 @@
 package eventlog
 
 import (
-	"github.com/xelaj/go-dry"
+	"context"
 	"io"
 	"time"
+
+	"github.com/xelaj/go-dry"
 )
 
+// Poller читает события из r и передает их в dest до конца файла или отмены ctx.
+// По завершении dest закрывается. Возвращает nil при достижении конца файла,
+// ошибку контекста при отмене и ошибку чтения в остальных случаях
 type Poller interface {
-	Poll(r EventReader, dest chan Event, stop chan struct{})
+	Poll(ctx context.Context, r EventReader, dest chan<- Event) error
 }
 
 type LongPoller struct {
-	Limit   int
+	Limit   int // Количество событий за одно чтение, по умолчанию 1000
 	Timeout time.Duration
 
+	// RateLimit ограничивает количество чтений в секунду, 0 - без ограничения
+	RateLimit float64
+
 	// AllowedSeverity contains the event types
 	// Possible values:
 	//	SeverityInfo  => "Информация"
@@ -22,39 +30,79 @@ type LongPoller struct {
 	//	SeverityNote  => "Примечание"
 	//
 	AllowedSeverity []SeverityType
-
-	LastErr error
 }
 
 // Poll does long polling.
-func (p *LongPoller) Poll(r EventReader, dest chan Event, stop chan struct{}) {
-
-	p.LastErr = nil
+// Прочитанная партия событий всегда передается в dest полностью, в том числе после отмены ctx,
+// чтобы позиция читателя соответствовала переданным событиям
+func (p *LongPoller) Poll(ctx context.Context, r EventReader, dest chan<- Event) error {
 
-	for {
+	defer close(dest)
 
-		select {
-		case <-stop:
-			return
-		default:
+	read := r.Read
+	if cr, ok := r.(CtxEventReader); ok {
+		read = func(limit int, timeout time.Duration) ([]Event, error) {
+			return cr.ReadCtx(ctx, limit, timeout)
 		}
+	}
+
+	// Читатель не возвращает событий при нулевом лимите, и чтение не закончится
+	limit := p.Limit
+	if limit <= 0 {
+		limit = defaultBulkSize
+	}
+
+	var interval time.Duration
+	if p.RateLimit > 0 {
+		interval = time.Duration(float64(time.Second) / p.RateLimit)
+	}
 
-		events, err := r.Read(p.Limit, p.Timeout)
-		p.LastErr = err
+	var last time.Time
 
-		if err != nil && err != io.EOF {
-			return
+	for {
+
+		if err := p.wait(ctx, last, interval); err != nil {
+			return err
 		}
+		last = time.Now()
+
+		events, err := read(limit, p.Timeout)
 
 		p.pushEvents(events, dest)
 
-		if p.LastErr == io.EOF {
-			return
+		switch {
+		case err == io.EOF:
+			return nil
+		case err != nil:
+			return err
 		}
 	}
 }
 
-func (p *LongPoller) pushEvents(events []Event, dest chan Event) {
+// wait ожидает, пока с последнего чтения пройдет interval
+func (p *LongPoller) wait(ctx context.Context, last time.Time, interval time.Duration) error {
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	delay := time.Until(last.Add(interval))
+	if interval <= 0 || delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (p *LongPoller) pushEvents(events []Event, dest chan<- Event) {
 
 	for _, event := range events {
 
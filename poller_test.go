@@ -0,0 +1,160 @@
+package eventlog
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// batchReader возвращает заданные партии событий, затем err
+type batchReader struct {
+	counterReader
+	batches int
+	err     error
+	ctx     bool
+}
+
+func (r *batchReader) Read(limit int, timeout time.Duration) ([]Event, error) {
+
+	if r.batches == 0 {
+		return nil, r.err
+	}
+	r.batches--
+
+	return r.counterReader.Read(limit, timeout)
+}
+
+// ctxBatchReader читатель с поддержкой контекста
+type ctxBatchReader struct {
+	batchReader
+}
+
+func (r *ctxBatchReader) ReadCtx(ctx context.Context, limit int, timeout time.Duration) ([]Event, error) {
+
+	r.ctx = true
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return r.Read(limit, timeout)
+}
+
+func collect(dest chan Event) chan int {
+
+	n := make(chan int, 1)
+	go func() {
+		count := 0
+		for range dest {
+			count++
+		}
+		n <- count
+	}()
+
+	return n
+}
+
+func TestLongPoller_Poll(t *testing.T) {
+
+	readErr := errors.New("read failed")
+
+	tests := []struct {
+		name   string
+		reader EventReader
+		err    error
+		events int
+	}{
+		{"eof", &batchReader{batches: 3, err: io.EOF}, nil, 30},
+		{"error", &batchReader{batches: 2, err: readErr}, readErr, 20},
+		{"ctx", &ctxBatchReader{batchReader{batches: 1, err: io.EOF}}, nil, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			dest := make(chan Event)
+			n := collect(dest)
+
+			err := (&LongPoller{Limit: 10}).Poll(context.Background(), tt.reader, dest)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("Poll() error = %v, want %v", err, tt.err)
+			}
+
+			// Канал закрыт поллером
+			if events := <-n; events != tt.events {
+				t.Errorf("events = %d, want %d", events, tt.events)
+			}
+		})
+	}
+
+	reader := &ctxBatchReader{batchReader{batches: 1, err: io.EOF}}
+	_ = (&LongPoller{Limit: 10}).Poll(context.Background(), reader, make(chan Event, 10))
+	if !reader.ctx {
+		t.Error("ReadCtx is not used")
+	}
+}
+
+func TestLongPoller_Cancel(t *testing.T) {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	reader := &batchReader{batches: 1, err: io.EOF}
+	dest := make(chan Event)
+	n := collect(dest)
+
+	if err := (&LongPoller{Limit: 10}).Poll(ctx, reader, dest); err != context.Canceled {
+		t.Errorf("Poll() error = %v, want %v", err, context.Canceled)
+	}
+	if events := <-n; events != 0 {
+		t.Errorf("events = %d after cancel, want 0", events)
+	}
+}
+
+func TestLongPoller_RateLimit(t *testing.T) {
+
+	reader := &batchReader{batches: 4, err: io.EOF}
+	dest := make(chan Event, 100)
+
+	start := time.Now()
+	if err := (&LongPoller{Limit: 1, RateLimit: 20}).Poll(context.Background(), reader, dest); err != nil {
+		t.Fatal(err)
+	}
+
+	// 5 чтений с интервалом 50ms
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("5 reads took %s, want at least 200ms", elapsed)
+	}
+}
+
+func TestLongPoller_DefaultLimit(t *testing.T) {
+
+	reader, err := NewLgpReader("./tests/20210108100000.lgp", LgpReaderOptions{Location: time.UTC})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	dest := make(chan Event)
+	n := collect(dest)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- (&LongPoller{}).Poll(context.Background(), reader, dest)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Poll() with zero limit does not reach end of file")
+	}
+
+	if events := <-n; events == 0 {
+		t.Error("no events read with zero limit")
+	}
+}
@@ -0,0 +1,367 @@
+package eventlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// OverflowPolicy поведение очереди хранилища при ее заполнении
+type OverflowPolicy int
+
+const (
+	OverflowBlock      OverflowPolicy = iota // Ожидать освобождения места в очереди
+	OverflowDropOldest                       // Удалять самое старое событие очереди
+	OverflowSpill                            // Записывать события во временный файл на диске
+)
+
+const defaultQueueSize = 1000
+
+type QueueOptions struct {
+	Size     int            // Количество событий в памяти, по умолчанию 1000
+	Overflow OverflowPolicy // По умолчанию OverflowBlock
+
+	// SpillDir каталог временных файлов для OverflowSpill, по умолчанию os.TempDir().
+	// Data событий из файла передается в виде, полученном при разборе JSON.
+	// Временные файлы не переносятся между запусками: Manager фиксирует позицию файла журнала
+	// только после передачи событий очереди (Flush), поэтому после сбоя они будут прочитаны повторно
+	SpillDir string
+}
+
+// QueueStats состояние очереди хранилища
+type QueueStats struct {
+	Queued    int           // Событий в памяти
+	Spilled   int           // Событий во временном файле
+	Dropped   uint64        // Удалено при переполнении
+	Delivered uint64        // Передано в хранилище
+	Lag       time.Duration // Время ожидания самого старого события очереди
+	LastDate  time.Time     // Время последнего переданного в хранилище события
+}
+
+var _ ExporterStorage = (*QueuedStorage)(nil)
+
+// QueuedStorage передает события в хранилище из ограниченной очереди в отдельной горутине,
+// чтобы медленное хранилище не задерживало чтение журнала и другие хранилища
+type QueuedStorage struct {
+	next ExporterStorage
+	opts QueueOptions
+
+	mu        sync.Mutex
+	cond      *sync.Cond
+	queue     []queuedEvent
+	spill     *spillFile
+	seq       uint64 // Номер последнего помещенного в очередь события
+	inFlight  bool
+	flightSeq uint64 // Номер передаваемого события
+	closed    bool
+	dropped   uint64
+	delivered uint64
+	lastDate  time.Time
+
+	done chan struct{}
+}
+
+type queuedEvent struct {
+	Seq    uint64
+	Queued time.Time
+	Event  Event
+}
+
+// NewQueuedStorage создает очередь перед хранилищем next и запускает передачу событий
+func NewQueuedStorage(next ExporterStorage, opts QueueOptions) *QueuedStorage {
+
+	if opts.Size <= 0 {
+		opts.Size = defaultQueueSize
+	}
+
+	q := &QueuedStorage{
+		next: next,
+		opts: opts,
+		done: make(chan struct{}),
+	}
+	q.cond = sync.NewCond(&q.mu)
+
+	go q.loop()
+
+	return q
+}
+
+// Storage возвращает хранилище, в которое передаются события очереди
+func (q *QueuedStorage) Storage() ExporterStorage {
+	return q.next
+}
+
+func (q *QueuedStorage) Push(event Event) {
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.opts.Overflow == OverflowBlock {
+		for len(q.queue) >= q.opts.Size && !q.closed {
+			q.cond.Wait()
+		}
+	}
+
+	if q.closed {
+		q.dropped++
+		return
+	}
+
+	q.seq++
+	item := queuedEvent{Seq: q.seq, Queued: time.Now(), Event: event}
+
+	switch {
+	case q.spill != nil:
+		// Пока во временном файле есть события, новые пишутся за ними, чтобы сохранить порядок
+		q.spillEvent(item)
+	case len(q.queue) < q.opts.Size:
+		q.queue = append(q.queue, item)
+	case q.opts.Overflow == OverflowSpill:
+		q.spillEvent(item)
+	default:
+		q.queue = append(q.queue[1:], item)
+		q.dropped++
+	}
+
+	q.cond.Broadcast()
+}
+
+func (q *QueuedStorage) spillEvent(item queuedEvent) {
+
+	if q.spill == nil {
+		spill, err := newSpillFile(q.opts.SpillDir)
+		if err != nil {
+			log.Printf("queue: spill: %s", err)
+			q.dropped++
+			return
+		}
+		q.spill = spill
+	}
+
+	if err := q.spill.Write(item); err != nil {
+		log.Printf("queue: spill: %s", err)
+		q.dropped++
+	}
+}
+
+func (q *QueuedStorage) loop() {
+
+	defer close(q.done)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for {
+		for len(q.queue) == 0 && q.spill == nil && !q.closed {
+			q.cond.Wait()
+		}
+
+		if len(q.queue) == 0 && q.spill != nil {
+			q.refill()
+			continue
+		}
+
+		if len(q.queue) == 0 {
+			// Очередь закрыта и пуста
+			return
+		}
+
+		item := q.queue[0]
+		q.queue = q.queue[1:]
+		q.inFlight = true
+		q.flightSeq = item.Seq
+		q.cond.Broadcast()
+		q.mu.Unlock()
+
+		q.next.Push(item.Event)
+
+		q.mu.Lock()
+		q.inFlight = false
+		q.delivered++
+		q.lastDate = item.Event.Date
+		q.cond.Broadcast()
+	}
+}
+
+// refill переносит события из временного файла в очередь
+func (q *QueuedStorage) refill() {
+
+	items, err := q.spill.Read(q.opts.Size)
+	if err != nil {
+		log.Printf("queue: spill: %s", err)
+		q.dropped += uint64(q.spill.count)
+		q.spill.count = 0
+	}
+
+	q.queue = append(q.queue, items...)
+
+	if q.spill.count == 0 {
+		if err := q.spill.Remove(); err != nil {
+			log.Printf("queue: spill: %s", err)
+		}
+		q.spill = nil
+	}
+}
+
+// Flush ожидает передачи событий, помещенных в очередь до его вызова, и вызывает Flush хранилища,
+// если оно его поддерживает. События, добавленные после вызова, например экспортерами других файлов,
+// не ожидаются, поэтому Flush не задерживается, пока в общую очередь продолжают поступать события
+func (q *QueuedStorage) Flush() error {
+
+	q.mu.Lock()
+	last := q.seq
+	for q.pending(last) {
+		q.cond.Wait()
+	}
+	q.mu.Unlock()
+
+	if f, ok := q.next.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+
+	return nil
+}
+
+// pending проверяет, что событие с номером не больше seq еще не передано.
+// События передаются по порядку номеров: сначала из памяти, затем из временного файла
+func (q *QueuedStorage) pending(seq uint64) bool {
+	switch {
+	case q.inFlight && q.flightSeq <= seq:
+		return true
+	case len(q.queue) > 0:
+		return q.queue[0].Seq <= seq
+	default:
+		// Очередь в памяти будет заполнена из временного файла
+		return q.spill != nil
+	}
+}
+
+// Stop передает оставшиеся события и останавливает очередь, не закрывая хранилище
+func (q *QueuedStorage) Stop() {
+
+	q.mu.Lock()
+	q.closed = true
+	q.cond.Broadcast()
+	q.mu.Unlock()
+
+	<-q.done
+}
+
+// Close останавливает очередь и закрывает хранилище, если оно это поддерживает
+func (q *QueuedStorage) Close() error {
+
+	q.Stop()
+
+	switch s := q.next.(type) {
+	case interface{ Close() error }:
+		return s.Close()
+	case interface{ Flush() error }:
+		return s.Flush()
+	}
+
+	return nil
+}
+
+func (q *QueuedStorage) Stats() QueueStats {
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stats := QueueStats{
+		Queued:    len(q.queue),
+		Dropped:   q.dropped,
+		Delivered: q.delivered,
+		LastDate:  q.lastDate,
+	}
+	if q.spill != nil {
+		stats.Spilled = q.spill.count
+	}
+	if len(q.queue) > 0 {
+		stats.Lag = time.Since(q.queue[0].Queued)
+	}
+
+	return stats
+}
+
+// spillFile временный файл событий, не поместившихся в очередь.
+// События записываются в конец файла и читаются с начала по одному в строке
+type spillFile struct {
+	file   *os.File
+	in     *os.File
+	reader *bufio.Reader
+	count  int
+}
+
+func newSpillFile(dir string) (*spillFile, error) {
+
+	file, err := ioutil.TempFile(dir, "eventlog-spill-*.jsonl")
+	if err != nil {
+		return nil, err
+	}
+
+	in, err := os.Open(file.Name())
+	if err != nil {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return nil, err
+	}
+
+	return &spillFile{
+		file:   file,
+		in:     in,
+		reader: bufio.NewReader(in),
+	}, nil
+}
+
+func (s *spillFile) Write(item queuedEvent) error {
+
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+
+	s.count++
+
+	return nil
+}
+
+func (s *spillFile) Read(limit int) ([]queuedEvent, error) {
+
+	var items []queuedEvent
+
+	for len(items) < limit && s.count > 0 {
+
+		line, err := s.reader.ReadBytes('\n')
+		if err != nil {
+			return items, err
+		}
+
+		var item queuedEvent
+		s.count--
+
+		if err := json.Unmarshal(line, &item); err != nil {
+			log.Printf("queue: spill: %s", err)
+			continue
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func (s *spillFile) Remove() error {
+
+	_ = s.in.Close()
+	_ = s.file.Close()
+
+	return os.Remove(s.file.Name())
+}
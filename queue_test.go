@@ -0,0 +1,206 @@
+package eventlog
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowStorage хранилище, которое принимает события только после release
+type slowStorage struct {
+	syncStorage
+	release chan struct{}
+}
+
+func (s *slowStorage) Push(event Event) {
+	<-s.release
+	s.syncStorage.Push(event)
+}
+
+func (s *slowStorage) offsets() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var offsets []int64
+	for _, e := range s.events {
+		offsets = append(offsets, e.Offset)
+	}
+	return offsets
+}
+
+func TestQueuedStorage_Overflow(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		overflow    OverflowPolicy
+		wantDropped uint64
+		wantFirst   int64
+	}{
+		{"drop oldest", OverflowDropOldest, 5, 6},
+		{"spill", OverflowSpill, 0, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			storage := &slowStorage{release: make(chan struct{})}
+			q := NewQueuedStorage(storage, QueueOptions{
+				Size:     5,
+				Overflow: tt.overflow,
+				SpillDir: t.TempDir(),
+			})
+
+			// Первое событие ожидает хранилище, остальные переполняют очередь
+			q.Push(Event{Offset: 0})
+			for q.Stats().Queued != 0 {
+				time.Sleep(time.Millisecond)
+			}
+			for i := int64(1); i <= 10; i++ {
+				q.Push(Event{Offset: i})
+			}
+
+			stats := q.Stats()
+			if stats.Dropped != tt.wantDropped {
+				t.Errorf("dropped = %d, want %d", stats.Dropped, tt.wantDropped)
+			}
+			if stats.Lag <= 0 {
+				t.Error("lag of waiting events not reported")
+			}
+
+			close(storage.release)
+			if err := q.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			offsets := storage.offsets()
+			if offsets[1] != tt.wantFirst {
+				t.Errorf("first event after overflow = %d, want %d", offsets[1], tt.wantFirst)
+			}
+			for i := 1; i < len(offsets); i++ {
+				if offsets[i] <= offsets[i-1] {
+					t.Fatalf("events out of order: %v", offsets)
+				}
+			}
+			if got := q.Stats().Delivered; got != uint64(len(offsets)) {
+				t.Errorf("delivered = %d, want %d", got, len(offsets))
+			}
+		})
+	}
+}
+
+func TestQueuedStorage_Block(t *testing.T) {
+
+	storage := &slowStorage{release: make(chan struct{})}
+	q := NewQueuedStorage(storage, QueueOptions{Size: 2})
+
+	pushed := make(chan struct{})
+	go func() {
+		defer close(pushed)
+		for i := 0; i < 5; i++ {
+			q.Push(Event{Offset: int64(i)})
+		}
+	}()
+
+	select {
+	case <-pushed:
+		t.Fatal("push not blocked by full queue")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(storage.release)
+	<-pushed
+
+	if err := q.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if n := storage.Len(); n != 5 {
+		t.Errorf("delivered %d events, want 5", n)
+	}
+
+	q.Stop()
+}
+
+func TestQueuedStorage_Independent(t *testing.T) {
+
+	slow := &slowStorage{release: make(chan struct{})}
+	fast := &syncStorage{}
+
+	queues := []*QueuedStorage{
+		NewQueuedStorage(slow, QueueOptions{Size: 100}),
+		NewQueuedStorage(fast, QueueOptions{Size: 100}),
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			for _, q := range queues {
+				q.Push(Event{Offset: int64(i)})
+			}
+		}
+	}()
+	wg.Wait()
+
+	// Медленное хранилище не задерживает остальные
+	if err := queues[1].Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if n := fast.Len(); n != 50 {
+		t.Errorf("fast storage got %d events, want 50", n)
+	}
+
+	close(slow.release)
+	for _, q := range queues {
+		q.Stop()
+	}
+}
+
+func TestQueuedStorage_FlushShared(t *testing.T) {
+
+	storage := &deliveryStorage{delay: 100 * time.Microsecond}
+	q := NewQueuedStorage(storage, QueueOptions{Size: 10})
+	defer q.Stop()
+
+	// Экспортер другого файла непрерывно добавляет события в общую очередь
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := int64(0); ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			q.Push(Event{JournalFile: "other.lgp", Offset: i, Size: 1})
+		}
+	}()
+	defer func() {
+		close(stop)
+		wg.Wait()
+	}()
+
+	for i := int64(0); i < 20; i++ {
+		q.Push(Event{JournalFile: "file.lgp", Offset: i, Size: 1})
+	}
+
+	flushed := make(chan error, 1)
+	go func() {
+		flushed <- q.Flush()
+	}()
+
+	select {
+	case err := <-flushed:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Flush waits for events pushed after it")
+	}
+
+	if end := storage.End("file.lgp"); end != 20 {
+		t.Errorf("delivered up to %d, want 20", end)
+	}
+}
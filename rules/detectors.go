@@ -0,0 +1,278 @@
+package rules
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/v8platform/eventlog"
+)
+
+const (
+	eventAuthenticationError = eventlog.EventType(eventlog.EventScopeSession) + "." + eventlog.EventType(eventlog.EventCauseAuthenticationError)
+	eventAuthentication      = eventlog.EventType(eventlog.EventScopeSession) + "." + eventlog.EventType(eventlog.EventCauseAuthentication)
+	eventAccessDenied        = eventlog.EventType(eventlog.EventScopeAccess) + "." + eventlog.EventType(eventlog.EventCauseAccessDenied)
+)
+
+var _ Detector = (*ThresholdDetector)(nil)
+
+// ThresholdDetector срабатывает, если за окно Window пришло Threshold подходящих событий с одинаковым ключом.
+// После срабатывания счетчик ключа сбрасывается. Ключи без событий в окне удаляются
+type ThresholdDetector struct {
+	Rule      string
+	Level     AlertLevel
+	Threshold int
+	Window    time.Duration
+	Match     func(event eventlog.Event) bool
+	Key       func(event eventlog.Event) string
+
+	events map[string][]eventlog.Event
+	swept  time.Time // Время последней очистки ключей
+}
+
+// NewAuthenticationErrorDetector правило повторяющихся ошибок аутентификации пользователя с одного компьютера
+func NewAuthenticationErrorDetector(threshold int, window time.Duration) *ThresholdDetector {
+	return &ThresholdDetector{
+		Rule:      "authentication-errors",
+		Level:     AlertCritical,
+		Threshold: threshold,
+		Window:    window,
+		Match: func(event eventlog.Event) bool {
+			return event.Event == eventAuthenticationError
+		},
+		Key: func(event eventlog.Event) string {
+			return eventUser(event) + "@" + event.Computer
+		},
+	}
+}
+
+// NewAccessDeniedDetector правило всплеска отказов в доступе пользователю
+func NewAccessDeniedDetector(threshold int, window time.Duration) *ThresholdDetector {
+	return &ThresholdDetector{
+		Rule:      "access-denied",
+		Level:     AlertWarning,
+		Threshold: threshold,
+		Window:    window,
+		Match: func(event eventlog.Event) bool {
+			return event.Event == eventAccessDenied
+		},
+		Key: func(event eventlog.Event) string {
+			return event.User
+		},
+	}
+}
+
+func (d *ThresholdDetector) Name() string {
+	return d.Rule
+}
+
+func (d *ThresholdDetector) Detect(event eventlog.Event) []Alert {
+
+	if d.Match == nil || !d.Match(event) {
+		return nil
+	}
+
+	if d.events == nil {
+		d.events = make(map[string][]eventlog.Event)
+	}
+
+	d.sweep(event.Date)
+
+	var key string
+	if d.Key != nil {
+		key = d.Key(event)
+	}
+
+	events := append(d.events[key], event)
+
+	// Отбрасываем события за пределами окна
+	start := 0
+	for start < len(events) && event.Date.Sub(events[start].Date) > d.Window {
+		start++
+	}
+	events = events[start:]
+
+	if len(events) < d.Threshold {
+		d.events[key] = events
+		return nil
+	}
+
+	delete(d.events, key)
+
+	return []Alert{{
+		Rule:     d.Rule,
+		Level:    d.Level,
+		Time:     event.Date,
+		Message:  fmt.Sprintf("%d событий <%s> за %s для <%s>", len(events), event.Event, d.Window, key),
+		User:     eventUser(event),
+		Computer: event.Computer,
+		Events:   events,
+	}}
+}
+
+// sweep удаляет ключи, все события которых вышли за пределы окна.
+// Ключи проверяются не чаще одного раза за окно
+func (d *ThresholdDetector) sweep(now time.Time) {
+
+	if now.Sub(d.swept) <= d.Window {
+		return
+	}
+	d.swept = now
+
+	for key, events := range d.events {
+		if now.Sub(events[len(events)-1].Date) > d.Window {
+			delete(d.events, key)
+		}
+	}
+}
+
+var _ Detector = (*UserChangeDetector)(nil)
+
+// UserChangeDetector срабатывает на добавление, изменение и удаление пользователей информационной базы
+type UserChangeDetector struct{}
+
+func (d *UserChangeDetector) Name() string {
+	return "user-changes"
+}
+
+func (d *UserChangeDetector) Detect(event eventlog.Event) []Alert {
+
+	if event.Event.Scope() != eventlog.EventScopeUser {
+		return nil
+	}
+
+	level := AlertWarning
+
+	switch event.Event.Cause() {
+	case eventlog.EventCauseNew, eventlog.EventCauseDelete:
+		level = AlertCritical
+	case eventlog.EventCauseUpdate:
+	default:
+		return nil
+	}
+
+	return []Alert{{
+		Rule:     d.Name(),
+		Level:    level,
+		Time:     event.Date,
+		Message:  fmt.Sprintf("%s <%s> пользователем <%s>", event.Event, changedUser(event), event.User),
+		User:     event.User,
+		Computer: event.Computer,
+		Events:   []eventlog.Event{event},
+	}}
+}
+
+var _ Detector = (*OffHoursDetector)(nil)
+
+// OffHoursDetector срабатывает на вход в приложения (по умолчанию Конфигуратор) вне рабочего времени.
+// Время события берется по часам сервера
+type OffHoursDetector struct {
+	Applications []eventlog.ApplicationType
+	WorkStart    time.Duration // Начало рабочего дня от полуночи, например 9 * time.Hour
+	WorkEnd      time.Duration // Окончание рабочего дня от полуночи
+	WorkDays     []time.Weekday
+}
+
+// NewDesignerOffHoursDetector правило входа в конфигуратор вне рабочего времени по будням
+func NewDesignerOffHoursDetector(workStart, workEnd time.Duration) *OffHoursDetector {
+	return &OffHoursDetector{
+		Applications: []eventlog.ApplicationType{eventlog.ApplicationDesigner},
+		WorkStart:    workStart,
+		WorkEnd:      workEnd,
+		WorkDays: []time.Weekday{
+			time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday,
+		},
+	}
+}
+
+func (d *OffHoursDetector) Name() string {
+	return "off-hours-login"
+}
+
+func (d *OffHoursDetector) Detect(event eventlog.Event) []Alert {
+
+	if event.Event != eventAuthentication || !d.matchApplication(event.Application) {
+		return nil
+	}
+
+	if d.workingTime(event.Date) {
+		return nil
+	}
+
+	return []Alert{{
+		Rule:     d.Name(),
+		Level:    AlertWarning,
+		Time:     event.Date,
+		Message:  fmt.Sprintf("Вход <%s> в <%s> вне рабочего времени", eventUser(event), event.Application),
+		User:     eventUser(event),
+		Computer: event.Computer,
+		Events:   []eventlog.Event{event},
+	}}
+}
+
+func (d *OffHoursDetector) matchApplication(application eventlog.ApplicationType) bool {
+
+	if len(d.Applications) == 0 {
+		return true
+	}
+
+	for _, app := range d.Applications {
+		if app == application {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (d *OffHoursDetector) workingTime(t time.Time) bool {
+
+	if len(d.WorkDays) > 0 {
+
+		workDay := false
+		for _, day := range d.WorkDays {
+			if t.Weekday() == day {
+				workDay = true
+				break
+			}
+		}
+
+		if !workDay {
+			return false
+		}
+	}
+
+	clock := time.Duration(t.Hour())*time.Hour +
+		time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second
+
+	return clock >= d.WorkStart && clock < d.WorkEnd
+}
+
+// eventUser возвращает пользователя события.
+// Для ошибок аутентификации пользователь берется из данных события
+func eventUser(event eventlog.Event) string {
+
+	if len(event.User) > 0 {
+		return event.User
+	}
+
+	if data, ok := event.Data.(map[string]interface{}); ok {
+		if name, ok := data["Имя"].(string); ok {
+			return name
+		}
+	}
+
+	return event.DataPresentation
+}
+
+// changedUser возвращает имя измененного пользователя из данных события
+func changedUser(event eventlog.Event) string {
+
+	if data, ok := event.Data.(map[string]interface{}); ok {
+		if name, ok := data["Имя"].(string); ok {
+			return name
+		}
+	}
+
+	return event.DataPresentation
+}
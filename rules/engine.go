@@ -0,0 +1,81 @@
+package rules
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/v8platform/eventlog"
+)
+
+type AlertLevel string
+
+const (
+	AlertWarning  AlertLevel = "warning"
+	AlertCritical AlertLevel = "critical"
+)
+
+// Alert срабатывание правила на потоке событий
+type Alert struct {
+	Rule     string
+	Level    AlertLevel
+	Time     time.Time // Время последнего события, вызвавшего срабатывание
+	Message  string
+	User     string
+	Computer string
+	Events   []eventlog.Event
+}
+
+// Detector правило проверки событий журнала регистрации
+type Detector interface {
+	Name() string
+	Detect(event eventlog.Event) []Alert
+}
+
+// Notifier получатель срабатываний правил
+type Notifier interface {
+	Notify(alert Alert) error
+}
+
+var _ eventlog.ExporterStorage = (*Engine)(nil)
+
+// Engine проверяет поток событий правилами и передает срабатывания получателям.
+// Подключается как хранилище экспортера
+type Engine struct {
+	mu        sync.Mutex
+	detectors []Detector
+	notifiers []Notifier
+}
+
+func NewEngine(detectors []Detector, notifiers []Notifier) *Engine {
+	return &Engine{
+		detectors: detectors,
+		notifiers: notifiers,
+	}
+}
+
+func (e *Engine) Push(event eventlog.Event) {
+
+	// Правила хранят состояние, поэтому события проверяются последовательно
+	e.mu.Lock()
+
+	var alerts []Alert
+	for _, detector := range e.detectors {
+		alerts = append(alerts, detector.Detect(event)...)
+	}
+
+	e.mu.Unlock()
+
+	for _, alert := range alerts {
+		e.notify(alert)
+	}
+}
+
+func (e *Engine) notify(alert Alert) {
+
+	for _, notifier := range e.notifiers {
+		if err := notifier.Notify(alert); err != nil {
+			log.Printf("rules: notify alert <%s>: %s", alert.Rule, err)
+		}
+	}
+}
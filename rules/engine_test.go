@@ -0,0 +1,186 @@
+package rules
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/v8platform/eventlog"
+)
+
+type collectNotifier struct {
+	alerts []Alert
+}
+
+func (n *collectNotifier) Notify(alert Alert) error {
+	n.alerts = append(n.alerts, alert)
+	return nil
+}
+
+func TestEngine(t *testing.T) {
+
+	// Суббота
+	begin := time.Date(2021, 1, 9, 23, 0, 0, 0, time.UTC)
+
+	at := func(sec int, event eventlog.Event) eventlog.Event {
+		event.Date = begin.Add(time.Duration(sec) * time.Second)
+		return event
+	}
+
+	authError := eventlog.Event{
+		Event:    eventAuthenticationError,
+		Computer: "pc-1",
+		Data:     map[string]interface{}{"Имя": "Бухгалтер"},
+	}
+
+	events := []eventlog.Event{
+		at(0, authError),
+		at(10, authError),
+		at(100, authError), // вне окна
+		at(110, authError),
+		at(120, authError),
+		at(130, eventlog.Event{Event: eventAccessDenied, User: "Бухгалтер"}),
+		at(140, eventlog.Event{Event: "_$User$_.New", User: "Администратор", DataPresentation: "Кассир"}),
+		at(150, eventlog.Event{Event: eventAuthentication, User: "Администратор", Application: eventlog.ApplicationDesigner}),
+		at(160, eventlog.Event{Event: eventAuthentication, User: "Администратор", Application: eventlog.Application1CV8C}),
+	}
+
+	notifier := &collectNotifier{}
+
+	engine := NewEngine([]Detector{
+		NewAuthenticationErrorDetector(3, time.Minute),
+		NewAccessDeniedDetector(2, time.Minute),
+		&UserChangeDetector{},
+		NewDesignerOffHoursDetector(9*time.Hour, 18*time.Hour),
+	}, []Notifier{notifier})
+
+	for _, event := range events {
+		engine.Push(event)
+	}
+
+	want := []string{"authentication-errors", "user-changes", "off-hours-login"}
+
+	if len(notifier.alerts) != len(want) {
+		t.Fatalf("alerts = %+v", notifier.alerts)
+	}
+
+	for i, alert := range notifier.alerts {
+		if alert.Rule != want[i] {
+			t.Errorf("alert %d rule = %s, want %s", i, alert.Rule, want[i])
+		}
+	}
+
+	if auth := notifier.alerts[0]; len(auth.Events) != 3 || auth.User != "Бухгалтер" {
+		t.Errorf("authentication alert = %+v", auth)
+	}
+
+	if change := notifier.alerts[1]; change.Level != AlertCritical || !strings.Contains(change.Message, "Кассир") {
+		t.Errorf("user change alert = %+v", change)
+	}
+}
+
+func TestThresholdDetector_expiredKeys(t *testing.T) {
+
+	d := NewAccessDeniedDetector(2, time.Minute)
+	begin := time.Date(2021, 1, 11, 9, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 50; i++ {
+		d.Detect(eventlog.Event{
+			Event: eventAccessDenied,
+			User:  fmt.Sprintf("user-%d", i),
+			Date:  begin.Add(time.Duration(i) * time.Second),
+		})
+	}
+
+	if len(d.events) != 50 {
+		t.Fatalf("keys = %d, want 50", len(d.events))
+	}
+
+	d.Detect(eventlog.Event{Event: eventAccessDenied, User: "last", Date: begin.Add(time.Hour)})
+
+	if len(d.events) != 1 {
+		t.Errorf("keys after window = %d, want 1", len(d.events))
+	}
+}
+
+func TestOffHoursDetector_workingTime(t *testing.T) {
+
+	d := NewDesignerOffHoursDetector(9*time.Hour, 18*time.Hour)
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want bool
+	}{
+		{"monday morning", time.Date(2021, 1, 11, 9, 0, 0, 0, time.UTC), true},
+		{"monday evening", time.Date(2021, 1, 11, 18, 0, 0, 0, time.UTC), false},
+		{"monday night", time.Date(2021, 1, 11, 3, 0, 0, 0, time.UTC), false},
+		{"sunday", time.Date(2021, 1, 10, 12, 0, 0, 0, time.UTC), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := d.workingTime(tt.t); got != tt.want {
+				t.Errorf("workingTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotifiers(t *testing.T) {
+
+	alert := Alert{
+		Rule:    "test",
+		Level:   AlertWarning,
+		Time:    time.Date(2021, 1, 9, 23, 0, 0, 0, time.UTC),
+		Message: "message",
+	}
+
+	buf := &bytes.Buffer{}
+	if err := NewWriterNotifier(buf).Notify(alert); err != nil {
+		t.Fatal(err)
+	}
+	if want := "2021-01-09T23:00:00Z [warning] test: message\n"; buf.String() != want {
+		t.Errorf("writer = %q, want %q", buf.String(), want)
+	}
+
+	file := filepath.Join(t.TempDir(), "alerts.jsonl")
+	fileNotifier := NewFileNotifier(file)
+	_ = fileNotifier.Notify(alert)
+	if err := fileNotifier.Notify(alert); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(file)
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Errorf("file lines = %d, want 2", lines)
+	}
+
+	var got Alert
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer ts.Close()
+
+	webhook := NewWebhookNotifier(ts.URL)
+	if err := webhook.Notify(alert); err == nil {
+		t.Error("webhook without auth: want error")
+	}
+
+	webhook.Headers = map[string]string{"Authorization": "Bearer token"}
+	if err := webhook.Notify(alert); err != nil {
+		t.Fatal(err)
+	}
+	if got.Rule != alert.Rule {
+		t.Errorf("webhook alert = %+v", got)
+	}
+}
@@ -0,0 +1,126 @@
+package rules
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	_ Notifier = (*WriterNotifier)(nil)
+	_ Notifier = (*FileNotifier)(nil)
+	_ Notifier = (*WebhookNotifier)(nil)
+)
+
+// WriterNotifier пишет срабатывания правил в текстовом виде
+type WriterNotifier struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterNotifier(w io.Writer) *WriterNotifier {
+	return &WriterNotifier{w: w}
+}
+
+// NewStdoutNotifier выводит срабатывания правил в стандартный вывод
+func NewStdoutNotifier() *WriterNotifier {
+	return NewWriterNotifier(os.Stdout)
+}
+
+func (n *WriterNotifier) Notify(alert Alert) error {
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	_, err := fmt.Fprintf(n.w, "%s [%s] %s: %s\n",
+		alert.Time.Format(time.RFC3339), alert.Level, alert.Rule, alert.Message)
+
+	return err
+}
+
+// FileNotifier дописывает срабатывания правил в файл, по одному JSON объекту на строку
+type FileNotifier struct {
+	mu   sync.Mutex
+	File string
+}
+
+func NewFileNotifier(file string) *FileNotifier {
+	return &FileNotifier{File: file}
+}
+
+func (n *FileNotifier) Notify(alert Alert) error {
+
+	data, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	f, err := os.OpenFile(n.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// WebhookNotifier отправляет срабатывания правил POST запросом в формате JSON
+type WebhookNotifier struct {
+	URL     string
+	Headers map[string]string
+	Client  *http.Client
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		URL:    url,
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (n *WebhookNotifier) Notify(alert Alert) error {
+
+	data, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, n.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range n.Headers {
+		req.Header.Set(key, value)
+	}
+
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("webhook: unexpected status <%s>", resp.Status)
+	}
+
+	return nil
+}
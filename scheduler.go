@@ -0,0 +1,126 @@
+package eventlog
+
+import (
+	"context"
+	"sync"
+)
+
+// fileScheduler запускает обработку файлов журнала регистрации.
+//
+// Для каждого файла работает не более одного обработчика, всего — не более size обработчиков.
+// Уведомления о записи в файл во время его обработки объединяются в признак dirty,
+// и после завершения обработка файла запускается еще раз.
+// Файлы, поставленные через Backfill, ограничиваются собственным лимитом обработчиков
+type fileScheduler struct {
+	run   func(ctx context.Context, file string)
+	slots chan struct{}
+
+	mu    sync.Mutex
+	files map[string]*scheduledFile
+	wg    sync.WaitGroup
+}
+
+type scheduledFile struct {
+	running bool          // Обработчик выполняется, а не ожидает очереди
+	dirty   bool          // Файл изменился во время обработки
+	done    chan struct{} // Закрывается после завершения обработки
+}
+
+func newFileScheduler(size int, run func(ctx context.Context, file string)) *fileScheduler {
+	return &fileScheduler{
+		run:   run,
+		slots: make(chan struct{}, size),
+		files: make(map[string]*scheduledFile),
+	}
+}
+
+// Schedule ставит файл в очередь обработки.
+// Если файл уже ожидает обработки, то повторно он не ставится
+func (s *fileScheduler) Schedule(ctx context.Context, file string) {
+	s.schedule(ctx, file, s.slots)
+}
+
+// Backfill ставит файл в очередь обработки с отдельным лимитом обработчиков slots.
+// Возвращает канал, который закрывается после завершения обработки файла.
+// Если файл уже обрабатывается, то ожидается текущая обработка
+func (s *fileScheduler) Backfill(ctx context.Context, file string, slots chan struct{}) <-chan struct{} {
+	return s.schedule(ctx, file, slots)
+}
+
+func (s *fileScheduler) schedule(ctx context.Context, file string, slots chan struct{}) <-chan struct{} {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if state, ok := s.files[file]; ok {
+		if state.running {
+			state.dirty = true
+		}
+		return state.done
+	}
+
+	state := &scheduledFile{done: make(chan struct{})}
+	s.files[file] = state
+	s.wg.Add(1)
+
+	go s.worker(ctx, file, state, slots)
+
+	return state.done
+}
+
+func (s *fileScheduler) worker(ctx context.Context, file string, state *scheduledFile, slots chan struct{}) {
+
+	defer s.wg.Done()
+	defer close(state.done)
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.mu.Lock()
+			delete(s.files, file)
+			s.mu.Unlock()
+			return
+		case slots <- struct{}{}:
+		}
+
+		s.mu.Lock()
+
+		if ctx.Err() != nil {
+			<-slots
+			delete(s.files, file)
+			s.mu.Unlock()
+			return
+		}
+
+		state.running = true
+		state.dirty = false
+		s.mu.Unlock()
+
+		s.run(ctx, file)
+
+		<-slots
+
+		s.mu.Lock()
+		state.running = false
+		if !state.dirty || ctx.Err() != nil {
+			delete(s.files, file)
+			s.mu.Unlock()
+			return
+		}
+		s.mu.Unlock()
+	}
+}
+
+// Running возвращает количество файлов, которые обрабатываются или ожидают обработки
+func (s *fileScheduler) Running() int {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.files)
+}
+
+// Wait ожидает завершения обработки всех запланированных файлов
+func (s *fileScheduler) Wait() {
+	s.wg.Wait()
+}
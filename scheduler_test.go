@@ -0,0 +1,161 @@
+package eventlog
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFileScheduler_Dirty(t *testing.T) {
+
+	var runs int32
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+
+	s := newFileScheduler(2, func(ctx context.Context, file string) {
+		atomic.AddInt32(&runs, 1)
+		started <- struct{}{}
+		<-release
+	})
+
+	ctx := context.Background()
+
+	s.Schedule(ctx, "a.lgp")
+	<-started
+
+	// Запись во время обработки объединяется в один повторный проход
+	s.Schedule(ctx, "a.lgp")
+	s.Schedule(ctx, "a.lgp")
+	s.Schedule(ctx, "a.lgp")
+
+	release <- struct{}{}
+	<-started
+	release <- struct{}{}
+
+	s.Wait()
+
+	if n := atomic.LoadInt32(&runs); n != 2 {
+		t.Errorf("runs = %d, want 2", n)
+	}
+	if n := s.Running(); n != 0 {
+		t.Errorf("running = %d after Wait", n)
+	}
+}
+
+func TestFileScheduler_Limits(t *testing.T) {
+
+	const poolSize = 3
+
+	var (
+		mu      sync.Mutex
+		perFile = make(map[string]int)
+		total   int
+		maxSeen int
+	)
+
+	s := newFileScheduler(poolSize, func(ctx context.Context, file string) {
+
+		mu.Lock()
+		perFile[file]++
+		total++
+		if perFile[file] > 1 {
+			t.Errorf("file %s processed concurrently", file)
+		}
+		if total > maxSeen {
+			maxSeen = total
+		}
+		mu.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		perFile[file]--
+		total--
+		mu.Unlock()
+	})
+
+	ctx := context.Background()
+
+	for i := 0; i < 200; i++ {
+		s.Schedule(ctx, fmt.Sprintf("%d.lgp", i%10))
+	}
+
+	s.Wait()
+
+	if maxSeen > poolSize {
+		t.Errorf("max concurrent workers = %d, want <= %d", maxSeen, poolSize)
+	}
+}
+
+func TestFileScheduler_Cancel(t *testing.T) {
+
+	release := make(chan struct{})
+	var runs int32
+
+	s := newFileScheduler(1, func(ctx context.Context, file string) {
+		atomic.AddInt32(&runs, 1)
+		<-release
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s.Schedule(ctx, "a.lgp")
+	s.Schedule(ctx, "b.lgp")
+	s.Schedule(ctx, "c.lgp")
+
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	close(release)
+
+	s.Wait()
+
+	// Файлы, ожидавшие очереди, после отмены не обрабатываются
+	if n := atomic.LoadInt32(&runs); n != 1 {
+		t.Errorf("runs = %d, want 1", n)
+	}
+}
+
+func TestFileScheduler_Backfill(t *testing.T) {
+
+	release := make(chan struct{})
+	started := make(chan string, 10)
+
+	s := newFileScheduler(1, func(ctx context.Context, file string) {
+		started <- file
+		<-release
+	})
+
+	ctx := context.Background()
+	backfill := make(chan struct{}, 1)
+
+	// Отдельный лимит не занимает обработчики обычного режима
+	done := s.Backfill(ctx, "old.lgp", backfill)
+	<-started
+	s.Schedule(ctx, "new.lgp")
+	<-started
+
+	// Для файла, который уже обрабатывается, ожидается его повторный проход
+	live := s.Backfill(ctx, "new.lgp", backfill)
+
+	close(release)
+
+	for _, c := range []<-chan struct{}{done, live} {
+		select {
+		case <-c:
+		case <-time.After(5 * time.Second):
+			t.Fatal("backfill not finished")
+		}
+	}
+
+	s.Wait()
+
+	if n := len(started); n != 1 {
+		t.Fatalf("extra runs = %d, want 1", n)
+	}
+	if file := <-started; file != "new.lgp" {
+		t.Errorf("extra run of %s, want new.lgp", file)
+	}
+}
@@ -0,0 +1,499 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/v8platform/eventlog"
+)
+
+const (
+	lgfFileName = "1Cv8.lgf"
+
+	defaultLimit    = 100
+	defaultMaxLimit = 1000
+	defaultReadSize = 500
+)
+
+var (
+	ErrInfobaseNotFound = errors.New("infobase not found")
+	ErrBadCursor        = errors.New("bad cursor")
+)
+
+// Infobase каталог журнала регистрации информационной базы
+type Infobase struct {
+	Name   string
+	Folder string
+}
+
+type Options struct {
+	Infobases []Infobase
+	MaxLimit  int            // Максимальное количество событий в ответе
+	ReadSize  int            // Количество событий читаемых из файла за раз
+	Location  *time.Location // Временная зона сервера 1С, по умолчанию time.Local
+}
+
+// Server http сервер для чтения журналов регистрации
+//
+//	GET /infobases
+//	GET /events?infobase=&from=&to=&user=&severity=&event=&limit=&cursor=
+//	GET /dictionary/{users,metadata,computers}?infobase=
+type Server struct {
+	infobases []Infobase
+	maxLimit  int
+	readSize  int
+	location  *time.Location
+
+	mux *http.ServeMux
+}
+
+var _ http.Handler = (*Server)(nil)
+
+func New(opt Options) *Server {
+
+	s := &Server{
+		infobases: opt.Infobases,
+		maxLimit:  defaultMaxLimit,
+		readSize:  defaultReadSize,
+		location:  time.Local,
+		mux:       http.NewServeMux(),
+	}
+
+	if opt.MaxLimit > 0 {
+		s.maxLimit = opt.MaxLimit
+	}
+	if opt.ReadSize > 0 {
+		s.readSize = opt.ReadSize
+	}
+	if opt.Location != nil {
+		s.location = opt.Location
+	}
+
+	s.mux.HandleFunc("/infobases", s.handleInfobases)
+	s.mux.HandleFunc("/events", s.handleEvents)
+	s.mux.HandleFunc("/dictionary/", s.handleDictionary)
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errors.New("method not allowed"))
+		return
+	}
+
+	s.mux.ServeHTTP(w, r)
+}
+
+// ListenAndServe запускает сервер на указанном адресе
+func (s *Server) ListenAndServe(addr string) error {
+	return http.ListenAndServe(addr, s)
+}
+
+type InfobaseInfo struct {
+	Name   string
+	Folder string
+	Files  []string
+}
+
+type EventsResponse struct {
+	Events     []eventlog.Event
+	NextCursor string `json:",omitempty"`
+}
+
+func (s *Server) handleInfobases(w http.ResponseWriter, _ *http.Request) {
+
+	infos := make([]InfobaseInfo, 0, len(s.infobases))
+
+	for _, ib := range s.infobases {
+
+		files, err := journalFiles(ib.Folder)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		infos = append(infos, InfobaseInfo{
+			Name:   ib.Name,
+			Folder: ib.Folder,
+			Files:  files,
+		})
+	}
+
+	writeJSON(w, infos)
+}
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+
+	ib, err := s.infobase(r.URL.Query().Get("infobase"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	q, err := s.parseQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := s.queryEvents(ib, q)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, resp)
+}
+
+func (s *Server) handleDictionary(w http.ResponseWriter, r *http.Request) {
+
+	var objectType int
+
+	switch strings.TrimPrefix(r.URL.Path, "/dictionary/") {
+	case "users":
+		objectType = eventlog.ObjectTypeUsers
+	case "metadata":
+		objectType = eventlog.ObjectTypeMetadata
+	case "computers":
+		objectType = eventlog.ObjectTypeComputers
+	default:
+		writeError(w, http.StatusNotFound, errors.New("unknown dictionary"))
+		return
+	}
+
+	ib, err := s.infobase(r.URL.Query().Get("infobase"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	lgf, err := os.Open(filepath.Join(ib.Folder, lgfFileName))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer lgf.Close()
+
+	items := eventlog.NewLgfReader(lgf).Dictionary(objectType)
+	if items == nil {
+		items = []eventlog.DictionaryItem{}
+	}
+
+	writeJSON(w, items)
+}
+
+// infobase возвращает информационную базу по имени.
+// Если имя не указано и база одна, то возвращается она
+func (s *Server) infobase(name string) (Infobase, error) {
+
+	if len(name) == 0 && len(s.infobases) == 1 {
+		return s.infobases[0], nil
+	}
+
+	for _, ib := range s.infobases {
+		if ib.Name == name {
+			return ib, nil
+		}
+	}
+
+	return Infobase{}, ErrInfobaseNotFound
+}
+
+type eventsQuery struct {
+	From     time.Time
+	To       time.Time
+	User     string
+	Severity []eventlog.SeverityType
+	Event    string
+	Limit    int
+	Cursor   cursor
+}
+
+func (s *Server) parseQuery(r *http.Request) (q eventsQuery, err error) {
+
+	values := r.URL.Query()
+
+	if q.From, err = parseTime(values.Get("from"), s.location); err != nil {
+		return q, fmt.Errorf("from: %w", err)
+	}
+
+	if q.To, err = parseTime(values.Get("to"), s.location); err != nil {
+		return q, fmt.Errorf("to: %w", err)
+	}
+
+	q.User = values.Get("user")
+	q.Event = values.Get("event")
+
+	if severity := values.Get("severity"); len(severity) > 0 {
+		for _, v := range strings.Split(severity, ",") {
+			q.Severity = append(q.Severity, eventlog.SeverityType(strings.TrimSpace(v)))
+		}
+	}
+
+	q.Limit = defaultLimit
+	if limit := values.Get("limit"); len(limit) > 0 {
+		if q.Limit, err = strconv.Atoi(limit); err != nil || q.Limit < 1 {
+			return q, fmt.Errorf("limit: bad value <%s>", limit)
+		}
+	}
+
+	if q.Limit > s.maxLimit {
+		q.Limit = s.maxLimit
+	}
+
+	if q.Cursor, err = decodeCursor(values.Get("cursor")); err != nil {
+		return q, err
+	}
+
+	return q, nil
+}
+
+func (q eventsQuery) match(event eventlog.Event) bool {
+
+	if !q.From.IsZero() && event.Date.Before(q.From) {
+		return false
+	}
+
+	if !q.To.IsZero() && event.Date.After(q.To) {
+		return false
+	}
+
+	if len(q.User) > 0 && event.User != q.User && event.UserUuid != q.User {
+		return false
+	}
+
+	if len(q.Event) > 0 && string(event.Event) != q.Event {
+		return false
+	}
+
+	if len(q.Severity) > 0 {
+
+		for _, severity := range q.Severity {
+			if event.Severity == severity {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return true
+}
+
+func (s *Server) queryEvents(ib Infobase, q eventsQuery) (EventsResponse, error) {
+
+	resp := EventsResponse{
+		Events: []eventlog.Event{},
+	}
+
+	files, err := journalFiles(ib.Folder)
+	if err != nil {
+		return resp, err
+	}
+
+	files = filterFiles(files, q, s.location)
+
+	for _, file := range files {
+
+		if len(q.Cursor.File) > 0 && file < q.Cursor.File {
+			continue
+		}
+
+		var offset int64
+		if file == q.Cursor.File {
+			offset = q.Cursor.Offset
+		}
+
+		next, done, err := s.readFile(ib, file, offset, q, &resp)
+		if err != nil {
+			return resp, err
+		}
+
+		if done {
+			return resp, nil
+		}
+
+		if next != nil {
+			resp.NextCursor = next.String()
+			return resp, nil
+		}
+	}
+
+	return resp, nil
+}
+
+// readFile читает события файла начиная со смещения и добавляет подходящие в ответ.
+// Возвращает курсор, если лимит выборки достигнут и признак окончания выборки
+func (s *Server) readFile(ib Infobase, file string, offset int64, q eventsQuery, resp *EventsResponse) (*cursor, bool, error) {
+
+	reader, err := eventlog.NewLgpReader(filepath.Join(ib.Folder, file), eventlog.LgpReaderOptions{
+		LgfDir:   ib.Folder,
+		Offset:   offset,
+		Location: s.location,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	defer reader.Close()
+
+	for {
+
+		events, err := reader.Read(s.readSize, 0)
+		if err != nil && err != io.EOF {
+			return nil, false, err
+		}
+
+		for _, event := range events {
+
+			// События в файле упорядочены по времени
+			if !q.To.IsZero() && event.Date.After(q.To) {
+				return nil, true, nil
+			}
+
+			if !q.match(event) {
+				continue
+			}
+
+			resp.Events = append(resp.Events, event)
+
+			if len(resp.Events) == q.Limit {
+				return &cursor{
+					File:   file,
+					Offset: event.Offset + event.Size,
+				}, false, nil
+			}
+		}
+
+		if err == io.EOF {
+			return nil, false, nil
+		}
+	}
+}
+
+// filterFiles отбрасывает файлы, которые не могут содержать события из периода выборки.
+// Имя файла журнала содержит время начала записи в него по часам сервера
+func filterFiles(files []string, q eventsQuery, loc *time.Location) []string {
+
+	var result []string
+
+	for i, file := range files {
+
+		if !q.To.IsZero() {
+			if start, err := fileStartTime(file, loc); err == nil && start.After(q.To) {
+				break
+			}
+		}
+
+		if !q.From.IsZero() && i+1 < len(files) {
+			if next, err := fileStartTime(files[i+1], loc); err == nil && !next.After(q.From) {
+				continue
+			}
+		}
+
+		result = append(result, file)
+	}
+
+	return result
+}
+
+func fileStartTime(file string, loc *time.Location) (time.Time, error) {
+	return time.ParseInLocation("20060102150405", strings.TrimSuffix(file, filepath.Ext(file)), loc)
+}
+
+func journalFiles(folder string) ([]string, error) {
+
+	matches, err := eventlog.JournalFiles(folder)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0, len(matches))
+	for _, match := range matches {
+		files = append(files, filepath.Base(match))
+	}
+
+	return files, nil
+}
+
+// parseTime разбирает время в формате RFC3339 или в формате журнала в зоне сервера
+func parseTime(value string, loc *time.Location) (time.Time, error) {
+
+	if len(value) == 0 {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	return time.ParseInLocation("20060102150405", value, loc)
+}
+
+// cursor позиция чтения журнала: файл и смещение в нем
+type cursor struct {
+	File   string
+	Offset int64
+}
+
+func (c cursor) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%d", c.File, c.Offset)))
+}
+
+func decodeCursor(value string) (cursor, error) {
+
+	if len(value) == 0 {
+		return cursor{}, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor{}, ErrBadCursor
+	}
+
+	idx := strings.LastIndex(string(data), ":")
+	if idx == -1 {
+		return cursor{}, ErrBadCursor
+	}
+
+	offset, err := strconv.ParseInt(string(data[idx+1:]), 10, 64)
+	if err != nil {
+		return cursor{}, ErrBadCursor
+	}
+
+	file := string(data[:idx])
+	if file != filepath.Base(file) {
+		return cursor{}, ErrBadCursor
+	}
+
+	return cursor{File: file, Offset: offset}, nil
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+
+	_ = json.NewEncoder(w).Encode(struct {
+		Error string
+	}{err.Error()})
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/v8platform/eventlog"
+)
+
+func newTestServer() *httptest.Server {
+	return httptest.NewServer(New(Options{
+		Infobases: []Infobase{
+			{Name: "test", Folder: "../tests"},
+		},
+		Location: time.UTC,
+	}))
+}
+
+func getJSON(t *testing.T, u string, v interface{}) int {
+
+	resp, err := http.Get(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatal(err)
+	}
+
+	return resp.StatusCode
+}
+
+func TestServer_Infobases(t *testing.T) {
+
+	ts := newTestServer()
+	defer ts.Close()
+
+	var infos []InfobaseInfo
+	getJSON(t, ts.URL+"/infobases", &infos)
+
+	if len(infos) != 1 || infos[0].Name != "test" {
+		t.Fatalf("infobases = %v", infos)
+	}
+
+	if len(infos[0].Files) != 1 || infos[0].Files[0] != "20210108100000.lgp" {
+		t.Errorf("files = %v", infos[0].Files)
+	}
+}
+
+func TestServer_EventsPagination(t *testing.T) {
+
+	ts := newTestServer()
+	defer ts.Close()
+
+	var all EventsResponse
+	getJSON(t, ts.URL+"/events?limit=10", &all)
+
+	if len(all.Events) != 10 {
+		t.Fatalf("len(Events) = %d, want 10", len(all.Events))
+	}
+
+	var page EventsResponse
+	var got []eventlog.Event
+	cursor := ""
+
+	for i := 0; i < 2; i++ {
+		page = EventsResponse{}
+		getJSON(t, ts.URL+"/events?limit=5&cursor="+url.QueryEscape(cursor), &page)
+		got = append(got, page.Events...)
+		cursor = page.NextCursor
+	}
+
+	if len(got) != 10 {
+		t.Fatalf("len(pages) = %d, want 10", len(got))
+	}
+
+	for i := range got {
+		if got[i].Offset != all.Events[i].Offset {
+			t.Errorf("event %d offset = %d, want %d", i, got[i].Offset, all.Events[i].Offset)
+		}
+	}
+}
+
+func TestServer_EventsFilter(t *testing.T) {
+
+	ts := newTestServer()
+	defer ts.Close()
+
+	var resp EventsResponse
+	getJSON(t, ts.URL+"/events?limit=20&event="+url.QueryEscape("_$Session$_.Authentication")+
+		"&from=2021-01-08T10:24:40Z", &resp)
+
+	if len(resp.Events) == 0 {
+		t.Fatal("no events")
+	}
+
+	for _, event := range resp.Events {
+		if event.Event != "_$Session$_.Authentication" {
+			t.Errorf("event = %s", event.Event)
+		}
+	}
+
+	var bad struct{ Error string }
+	if code := getJSON(t, ts.URL+"/events?cursor=@@", &bad); code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", code, http.StatusBadRequest)
+	}
+}
+
+func TestServer_Dictionary(t *testing.T) {
+
+	ts := newTestServer()
+	defer ts.Close()
+
+	var users []eventlog.DictionaryItem
+	getJSON(t, ts.URL+"/dictionary/users", &users)
+
+	if len(users) == 0 {
+		t.Fatal("no users")
+	}
+
+	for _, user := range users {
+		if len(user.Uuid) == 0 {
+			t.Errorf("user %d without uuid", user.Id)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package eventlog
+
+import (
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SessionSummary сводная информация по сеансу информационной базы
+type SessionSummary struct {
+	Infobase      string // Каталог информационной базы
+	Session       int64
+	Connection    int64
+	User          string
+	UserUuid      string
+	Computer      string
+	Application   ApplicationType
+	Start         time.Time // Время начала сеанса или первого прочитанного события сеанса
+	End           time.Time // Время завершения сеанса или последнего события сеанса
+	Started       bool      // Получено событие начала сеанса
+	Finished      bool      // Получено событие завершения сеанса
+	Authenticated bool
+	Events        int
+}
+
+func (s SessionSummary) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+type sessionKey struct {
+	infobase string
+	session  int64
+}
+
+var _ ExporterStorage = (*SessionTracker)(nil)
+
+// SessionTracker восстанавливает сеансы по событиям _$Session$_ и отслеживает активные сеансы информационных баз.
+//
+// Сводка по сеансу передается обработчику при завершении сеанса,
+// при повторном начале сеанса с тем же номером и при вызове FlushAll.
+// Трекер не реализует Flush, т.к. Manager вызывает его после каждого чтения файла
+type SessionTracker struct {
+	handler func(session SessionSummary)
+
+	mu     sync.Mutex
+	active map[sessionKey]*SessionSummary
+}
+
+// NewSessionTracker создает трекер сеансов, handler вызывается для каждого завершенного сеанса
+func NewSessionTracker(handler func(session SessionSummary)) *SessionTracker {
+	return &SessionTracker{
+		handler: handler,
+		active:  make(map[sessionKey]*SessionSummary),
+	}
+}
+
+func (t *SessionTracker) Push(event Event) {
+
+	if event.Session == 0 {
+		return
+	}
+
+	var completed []SessionSummary
+
+	t.mu.Lock()
+
+	key := sessionKey{
+		infobase: eventInfobase(event),
+		session:  event.Session,
+	}
+
+	isSession := event.Event.Scope() == EventScopeSession
+	cause := event.Event.Cause()
+
+	session, ok := t.active[key]
+
+	if ok && isSession && cause == EventCauseStart {
+		// Номер сеанса использован повторно, а завершение предыдущего не получено
+		completed = append(completed, *session)
+		ok = false
+	}
+
+	if !ok {
+		session = &SessionSummary{
+			Infobase:   key.infobase,
+			Session:    event.Session,
+			Connection: event.Connection,
+			Start:      event.Date,
+		}
+		t.active[key] = session
+	}
+
+	session.End = event.Date
+	session.Events++
+
+	if len(session.User) == 0 || len(event.UserUuid) > 0 {
+		session.User, session.UserUuid = event.User, event.UserUuid
+	}
+	if len(session.Computer) == 0 {
+		session.Computer = event.Computer
+	}
+	if len(session.Application) == 0 {
+		session.Application = event.Application
+	}
+
+	if isSession {
+		switch cause {
+		case EventCauseStart:
+			session.Started = true
+		case EventCauseAuthentication:
+			session.Authenticated = true
+		case EventCauseFinish:
+			session.Finished = true
+			completed = append(completed, *session)
+			delete(t.active, key)
+		}
+	}
+
+	t.mu.Unlock()
+
+	t.emit(completed)
+}
+
+// Active возвращает активные сеансы информационной базы, упорядоченные по номеру сеанса
+func (t *SessionTracker) Active(infobase string) []SessionSummary {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var sessions []SessionSummary
+
+	for key, session := range t.active {
+		if key.infobase == infobase {
+			sessions = append(sessions, *session)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Session < sessions[j].Session
+	})
+
+	return sessions
+}
+
+// Infobases возвращает информационные базы, по которым есть активные сеансы
+func (t *SessionTracker) Infobases() []string {
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	seen := make(map[string]struct{})
+	var infobases []string
+
+	for key := range t.active {
+		if _, ok := seen[key.infobase]; ok {
+			continue
+		}
+		seen[key.infobase] = empty
+		infobases = append(infobases, key.infobase)
+	}
+
+	sort.Strings(infobases)
+
+	return infobases
+}
+
+// FlushAll передает обработчику все активные сеансы, например по окончании чтения каталога
+func (t *SessionTracker) FlushAll() {
+
+	t.mu.Lock()
+
+	completed := make([]SessionSummary, 0, len(t.active))
+	for key, session := range t.active {
+		completed = append(completed, *session)
+		delete(t.active, key)
+	}
+
+	t.mu.Unlock()
+
+	sort.Slice(completed, func(i, j int) bool {
+		return completed[i].Start.Before(completed[j].Start)
+	})
+
+	t.emit(completed)
+}
+
+func (t *SessionTracker) emit(completed []SessionSummary) {
+
+	if t.handler == nil {
+		return
+	}
+
+	for _, session := range completed {
+		t.handler(session)
+	}
+}
+
+// eventInfobase возвращает каталог информационной базы, из журнала которой прочитано событие
+func eventInfobase(event Event) string {
+
+	if len(event.InfobaseDir) > 0 {
+		return event.InfobaseDir
+	}
+
+	if len(event.JournalFile) == 0 {
+		return ""
+	}
+
+	return journalInfobaseDir(filepath.Dir(event.JournalFile))
+}
@@ -0,0 +1,142 @@
+package eventlog
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSessionTracker(t *testing.T) {
+
+	begin := time.Date(2021, 1, 8, 10, 24, 40, 0, time.UTC)
+
+	sessionEvent := func(session int64, event EventType, sec int) Event {
+		return Event{
+			Date:        begin.Add(time.Duration(sec) * time.Second),
+			Session:     session,
+			User:        "Администратор",
+			Application: "1CV8C",
+			Event:       event,
+			JournalFile: "/srv/ib/1Cv8Log/20210108100000.lgp",
+			InfobaseDir: "/srv/ib",
+		}
+	}
+
+	events := []Event{
+		sessionEvent(1, "_$Session$_.Start", 0),
+		sessionEvent(1, "_$Session$_.Authentication", 1),
+		sessionEvent(2, "_$Session$_.Start", 2),
+		sessionEvent(1, "_$Data$_.New", 3),
+		sessionEvent(1, "_$Session$_.Finish", 10),
+		sessionEvent(3, "_$Data$_.New", 11),
+		{Date: begin, Event: "_$Session$_.AuthenticationError"},
+	}
+
+	var got []SessionSummary
+
+	tracker := NewSessionTracker(func(session SessionSummary) {
+		got = append(got, session)
+	})
+
+	for _, event := range events {
+		tracker.Push(event)
+	}
+
+	if len(got) != 1 {
+		t.Fatalf("len(sessions) = %d, want 1", len(got))
+	}
+
+	first := got[0]
+	if first.Session != 1 || !first.Started || !first.Finished || !first.Authenticated ||
+		first.Events != 4 || first.Duration() != 10*time.Second || first.Infobase != "/srv/ib" {
+		t.Errorf("session = %+v", first)
+	}
+
+	if infobases := tracker.Infobases(); len(infobases) != 1 || infobases[0] != "/srv/ib" {
+		t.Errorf("Infobases() = %v", infobases)
+	}
+
+	active := tracker.Active("/srv/ib")
+	if len(active) != 2 || active[0].Session != 2 || active[1].Session != 3 || active[1].Started {
+		t.Errorf("Active() = %+v", active)
+	}
+
+	tracker.FlushAll()
+
+	if len(got) != 3 || got[1].Finished || got[2].Finished {
+		t.Errorf("flushed sessions = %+v", got[1:])
+	}
+}
+
+func TestSessionTracker_ExportFolder(t *testing.T) {
+
+	var finished int
+
+	tracker := NewSessionTracker(func(session SessionSummary) {
+		if session.Finished {
+			finished++
+		}
+	})
+
+	err := ExportFolder("./tests", []ExporterStorage{tracker}, LgpReaderOptions{
+		Location: time.UTC,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if finished == 0 {
+		t.Error("no finished sessions")
+	}
+}
+
+func TestSessionTracker_Manager(t *testing.T) {
+
+	dir, file, _ := copyTestJournal(t, -1)
+
+	// Активные сеансы по всему файлу без Manager
+	reference := NewSessionTracker(nil)
+	if err := ExportFolder(dir, []ExporterStorage{reference}, LgpReaderOptions{Location: time.UTC}); err != nil {
+		t.Fatal(err)
+	}
+
+	tracker := NewSessionTracker(nil)
+	journal := NewInMemoryJournal()
+
+	m := NewManager(context.Background(), ManagerOptions{
+		Folder:         []string{dir},
+		PoolSize:       1,
+		JournalStorage: journal,
+		Exporters:      []ExporterStorage{tracker},
+		Notifier:       NewPollingNotifier(10 * time.Millisecond),
+		Backfill:       true,
+	})
+	defer m.Stop()
+
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	abs, _ := filepath.Abs(file)
+
+	deadline := time.Now().Add(10 * time.Second)
+	for journal.GetOffset(abs) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if journal.GetOffset(abs) == 0 {
+		t.Fatal("offset not committed")
+	}
+
+	// После чтения файла сеансы остаются активными
+	infobases := reference.Infobases()
+	if len(infobases) == 0 {
+		t.Fatal("no active sessions in test journal")
+	}
+
+	for _, infobase := range infobases {
+		if got, want := len(tracker.Active(infobase)), len(reference.Active(infobase)); got != want {
+			t.Errorf("active sessions of <%s> = %d, want %d", infobase, got, want)
+		}
+	}
+}
@@ -0,0 +1,624 @@
+package eventlog
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EventWriter хранилище, которое подтверждает запись пачки событий
+type EventWriter interface {
+	WriteEvents(events []Event) error
+}
+
+const (
+	defaultSpoolSegmentSize      = 64 << 20
+	defaultSpoolMaxSize          = 1 << 30
+	defaultSpoolBatchSize        = 500
+	defaultSpoolRetryInterval    = time.Second
+	defaultSpoolMaxRetryInterval = time.Minute
+
+	spoolSegmentExt = ".spool"
+	spoolCursorFile = "cursor.json"
+	spoolHeaderSize = 8 // Длина и контрольная сумма записи
+)
+
+type SpoolOptions struct {
+	Dir         string // Каталог файлов сегментов
+	SegmentSize int64  // Размер файла сегмента, по умолчанию 64 МБ
+
+	// MaxSize общий размер сегментов, по умолчанию 1 ГБ.
+	// При превышении удаляются самые старые сегменты вместе с непереданными событиями
+	MaxSize int64
+
+	BatchSize        int           // Количество событий в пачке, по умолчанию 500
+	RetryInterval    time.Duration // Пауза после ошибки записи, по умолчанию 1 секунда, далее удваивается
+	MaxRetryInterval time.Duration // По умолчанию 1 минута
+}
+
+// SpoolStats состояние очереди на диске
+type SpoolStats struct {
+	Segments int
+	Size     int64  // Размер всех сегментов
+	Pending  int64  // Размер еще не переданных событий
+	Dropped  uint64 // Удалено сегментов при превышении MaxSize
+	LastErr  error  // Последняя ошибка записи в хранилище
+}
+
+var _ ExporterStorage = (*Spool)(nil)
+
+// Spool сохраняет события в файлы сегментов на диске и передает их в хранилище пачками.
+//
+// Если хранилище недоступно, события накапливаются на диске, а пачка передается повторно,
+// пока запись не завершится успешно. Позиция переданных событий сохраняется в файл,
+// поэтому после перезапуска передача продолжается с нее. Записи сегментов содержат
+// контрольную сумму, недописанная при сбое запись в конце сегмента отбрасывается.
+// Data событий передается в виде, полученном при разборе JSON
+type Spool struct {
+	next EventWriter
+	opts SpoolOptions
+
+	mu       sync.Mutex
+	segments []spoolSegment // Упорядочены по номеру, последний дописывается
+	active   *os.File
+	cursor   spoolPosition
+	dropped  uint64
+	lastErr  error
+	writeErr error // Ошибка сохранения события на диск, возвращается Flush
+	closed   bool
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+type spoolSegment struct {
+	seq  uint64
+	size int64
+}
+
+// spoolPosition позиция следующего непереданного события
+type spoolPosition struct {
+	Segment uint64
+	Offset  int64
+}
+
+// NewSpool открывает каталог сегментов и начинает передачу сохраненных в нем событий в next
+func NewSpool(next EventWriter, opts SpoolOptions) (*Spool, error) {
+
+	if len(opts.Dir) == 0 {
+		return nil, errors.New("spool: dir is required")
+	}
+	if opts.SegmentSize <= 0 {
+		opts.SegmentSize = defaultSpoolSegmentSize
+	}
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = defaultSpoolMaxSize
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultSpoolBatchSize
+	}
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = defaultSpoolRetryInterval
+	}
+	if opts.MaxRetryInterval <= 0 {
+		opts.MaxRetryInterval = defaultSpoolMaxRetryInterval
+	}
+
+	if err := os.MkdirAll(opts.Dir, 0755); err != nil {
+		return nil, err
+	}
+
+	s := &Spool{
+		next: next,
+		opts: opts,
+		wake: make(chan struct{}, 1),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+
+	go s.loop()
+
+	return s, nil
+}
+
+// open загружает сегменты и позицию, отбрасывая недописанную запись последнего сегмента
+func (s *Spool) open() error {
+
+	files, err := ioutil.ReadDir(s.opts.Dir)
+	if err != nil {
+		return err
+	}
+
+	for _, info := range files {
+		name := info.Name()
+		if info.IsDir() || filepath.Ext(name) != spoolSegmentExt {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, spoolSegmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		s.segments = append(s.segments, spoolSegment{seq: seq, size: info.Size()})
+	}
+
+	sort.Slice(s.segments, func(i, j int) bool {
+		return s.segments[i].seq < s.segments[j].seq
+	})
+
+	data, err := ioutil.ReadFile(filepath.Join(s.opts.Dir, spoolCursorFile))
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return err
+	default:
+		if err := json.Unmarshal(data, &s.cursor); err != nil {
+			return fmt.Errorf("spool: cursor: %w", err)
+		}
+	}
+
+	if len(s.segments) == 0 {
+		// Номера сегментов не повторяются, чтобы сохраненная позиция не указывала на новые события
+		seq := s.cursor.Segment
+		if seq == 0 {
+			seq = 1
+		}
+		s.segments = append(s.segments, spoolSegment{seq: seq})
+		s.cursor = spoolPosition{Segment: seq}
+	}
+
+	last := &s.segments[len(s.segments)-1]
+
+	size, err := validSegmentSize(s.segmentFile(last.seq))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if size != last.size {
+		log.Printf("spool: truncate %s to %d bytes", s.segmentFile(last.seq), size)
+	}
+	last.size = size
+
+	s.active, err = os.OpenFile(s.segmentFile(last.seq), os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if err := s.active.Truncate(size); err != nil {
+		return err
+	}
+	if _, err := s.active.Seek(size, io.SeekStart); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validSegmentSize возвращает размер сегмента без недописанной или поврежденной записи в конце
+func validSegmentSize(file string) (int64, error) {
+
+	f, err := os.Open(file)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	var offset int64
+
+	for {
+		_, n, err := readSpoolRecord(f, offset, info.Size())
+		if err != nil {
+			return offset, nil
+		}
+		offset += n
+	}
+}
+
+func (s *Spool) segmentFile(seq uint64) string {
+	return filepath.Join(s.opts.Dir, fmt.Sprintf("%016d%s", seq, spoolSegmentExt))
+}
+
+func (s *Spool) Push(event Event) {
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("spool: encode event <%s>: %s", event.Key(), err)
+		s.mu.Lock()
+		s.failWrite(fmt.Errorf("spool: encode event <%s>: %w", event.Key(), err))
+		s.mu.Unlock()
+		return
+	}
+
+	record := make([]byte, spoolHeaderSize+len(payload))
+	binary.LittleEndian.PutUint32(record[0:], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(record[4:], crc32.ChecksumIEEE(payload))
+	copy(record[spoolHeaderSize:], payload)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		log.Printf("spool: push to closed spool, event <%s> dropped", event.Key())
+		s.failWrite(fmt.Errorf("spool: push to closed spool, event <%s> dropped", event.Key()))
+		return
+	}
+
+	last := &s.segments[len(s.segments)-1]
+
+	if last.size > 0 && last.size+int64(len(record)) > s.opts.SegmentSize {
+		if err := s.rotate(); err != nil {
+			log.Printf("spool: rotate: %s", err)
+			s.failWrite(fmt.Errorf("spool: rotate: %w", err))
+			return
+		}
+		last = &s.segments[len(s.segments)-1]
+	}
+
+	if _, err := s.active.Write(record); err != nil {
+		log.Printf("spool: write: %s", err)
+		s.failWrite(fmt.Errorf("spool: write: %w", err))
+		// Недописанная запись будет перезаписана следующей
+		_, _ = s.active.Seek(last.size, io.SeekStart)
+		return
+	}
+	last.size += int64(len(record))
+
+	s.enforceMaxSize()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// failWrite запоминает первую ошибку сохранения события до вызова Flush
+func (s *Spool) failWrite(err error) {
+	if s.writeErr == nil {
+		s.writeErr = err
+	}
+}
+
+// rotate закрывает текущий сегмент и начинает новый.
+// Новый файл создается после сохранения предыдущего на диск, поэтому недописанной при сбое
+// может оказаться только запись в конце последнего сегмента
+func (s *Spool) rotate() error {
+
+	if err := s.active.Sync(); err != nil {
+		return err
+	}
+
+	seq := s.segments[len(s.segments)-1].seq + 1
+
+	active, err := os.OpenFile(s.segmentFile(seq), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	_ = s.active.Close()
+	s.active = active
+	s.segments = append(s.segments, spoolSegment{seq: seq})
+
+	return nil
+}
+
+// enforceMaxSize удаляет самые старые сегменты, пока общий размер превышает MaxSize
+func (s *Spool) enforceMaxSize() {
+
+	for len(s.segments) > 1 && s.size() > s.opts.MaxSize {
+
+		oldest := s.segments[0]
+		if err := os.Remove(s.segmentFile(oldest.seq)); err != nil && !os.IsNotExist(err) {
+			log.Printf("spool: remove segment: %s", err)
+			return
+		}
+		s.segments = s.segments[1:]
+		s.dropped++
+
+		if s.cursor.Segment <= oldest.seq {
+			log.Printf("spool: max size exceeded, segment %d dropped", oldest.seq)
+			s.cursor = spoolPosition{Segment: s.segments[0].seq}
+		}
+	}
+}
+
+func (s *Spool) size() int64 {
+	var size int64
+	for _, seg := range s.segments {
+		size += seg.size
+	}
+	return size
+}
+
+func (s *Spool) loop() {
+
+	defer close(s.done)
+
+	backoff := s.opts.RetryInterval
+
+	for {
+		s.mu.Lock()
+		events, next, err := s.read()
+		s.mu.Unlock()
+
+		if err != nil {
+			log.Printf("spool: read: %s", err)
+		}
+
+		if len(events) == 0 {
+			select {
+			case <-s.stop:
+				return
+			case <-s.wake:
+			}
+			continue
+		}
+
+		if err := s.next.WriteEvents(events); err != nil {
+
+			s.mu.Lock()
+			s.lastErr = err
+			s.mu.Unlock()
+
+			log.Printf("spool: write %d events: %s", len(events), err)
+
+			select {
+			case <-s.stop:
+				return
+			case <-time.After(backoff):
+			}
+
+			backoff *= 2
+			if backoff > s.opts.MaxRetryInterval {
+				backoff = s.opts.MaxRetryInterval
+			}
+			continue
+		}
+
+		backoff = s.opts.RetryInterval
+
+		s.mu.Lock()
+		s.lastErr = nil
+		s.commit(next)
+		s.mu.Unlock()
+	}
+}
+
+// read читает пачку событий с текущей позиции. Вызывается под s.mu
+func (s *Spool) read() ([]Event, spoolPosition, error) {
+
+	pos := s.cursor
+
+	var (
+		events []Event
+		file   *os.File
+		seq    uint64
+	)
+
+	defer func() {
+		if file != nil {
+			_ = file.Close()
+		}
+	}()
+
+	for len(events) < s.opts.BatchSize {
+
+		i := s.segmentIndex(pos.Segment)
+		if i < 0 {
+			return events, pos, nil
+		}
+		seg := s.segments[i]
+
+		if seg.seq != pos.Segment {
+			// Сегмент позиции удален, передача продолжается со следующего
+			pos = spoolPosition{Segment: seg.seq}
+		}
+
+		if pos.Offset >= seg.size {
+			if i == len(s.segments)-1 {
+				return events, pos, nil
+			}
+			pos = spoolPosition{Segment: s.segments[i+1].seq}
+			continue
+		}
+
+		if file == nil || seq != seg.seq {
+			if file != nil {
+				_ = file.Close()
+			}
+			f, err := os.Open(s.segmentFile(seg.seq))
+			if err != nil {
+				return events, pos, err
+			}
+			file, seq = f, seg.seq
+		}
+
+		payload, n, err := readSpoolRecord(file, pos.Offset, seg.size)
+		if err != nil {
+			// Поврежденный остаток сегмента пропускается
+			log.Printf("spool: segment %d at %d: %s", seg.seq, pos.Offset, err)
+			pos.Offset = seg.size
+			continue
+		}
+		pos.Offset += n
+
+		var event Event
+		if err := json.Unmarshal(payload, &event); err != nil {
+			log.Printf("spool: decode event: %s", err)
+			continue
+		}
+
+		events = append(events, event)
+	}
+
+	return events, pos, nil
+}
+
+// segmentIndex возвращает индекс сегмента с номером seq или следующего за ним
+func (s *Spool) segmentIndex(seq uint64) int {
+	for i, seg := range s.segments {
+		if seg.seq >= seq {
+			return i
+		}
+	}
+	return -1
+}
+
+// commit сохраняет позицию переданных событий и удаляет полностью переданные сегменты.
+// Вызывается под s.mu
+func (s *Spool) commit(pos spoolPosition) {
+
+	// Сегменты могли быть удалены при превышении размера во время записи в хранилище
+	if pos.Segment < s.cursor.Segment {
+		return
+	}
+	s.cursor = pos
+
+	if err := s.saveCursor(); err != nil {
+		log.Printf("spool: save cursor: %s", err)
+		return
+	}
+
+	for len(s.segments) > 1 && s.segments[0].seq < s.cursor.Segment {
+		if err := os.Remove(s.segmentFile(s.segments[0].seq)); err != nil && !os.IsNotExist(err) {
+			log.Printf("spool: remove segment: %s", err)
+			return
+		}
+		s.segments = s.segments[1:]
+	}
+}
+
+func (s *Spool) saveCursor() error {
+
+	data, err := json.Marshal(s.cursor)
+	if err != nil {
+		return err
+	}
+
+	file := filepath.Join(s.opts.Dir, spoolCursorFile)
+	tmp := file + ".tmp"
+
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, file)
+}
+
+// Flush сохраняет записанные события на диск.
+// Если событие с прошлого вызова Flush не удалось сохранить, то возвращается ошибка,
+// чтобы позиция файла журнала не была зафиксирована за ним
+func (s *Spool) Flush() error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.writeErr
+	s.writeErr = nil
+
+	if s.closed {
+		return err
+	}
+
+	if syncErr := s.active.Sync(); err == nil {
+		err = syncErr
+	}
+
+	return err
+}
+
+// Close останавливает передачу событий. Непереданные события остаются на диске
+func (s *Spool) Close() error {
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	close(s.stop)
+	<-s.done
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.active.Sync(); err != nil {
+		_ = s.active.Close()
+		return err
+	}
+
+	return s.active.Close()
+}
+
+func (s *Spool) Stats() SpoolStats {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := SpoolStats{
+		Segments: len(s.segments),
+		Size:     s.size(),
+		Dropped:  s.dropped,
+		LastErr:  s.lastErr,
+	}
+
+	for _, seg := range s.segments {
+		switch {
+		case seg.seq > s.cursor.Segment:
+			stats.Pending += seg.size
+		case seg.seq == s.cursor.Segment:
+			stats.Pending += seg.size - s.cursor.Offset
+		}
+	}
+
+	return stats
+}
+
+var errSpoolRecord = errors.New("bad record")
+
+// readSpoolRecord читает запись сегмента размера size и возвращает ее данные и размер вместе с заголовком
+func readSpoolRecord(r io.ReaderAt, offset, size int64) ([]byte, int64, error) {
+
+	header := make([]byte, spoolHeaderSize)
+	if _, err := r.ReadAt(header, offset); err != nil {
+		return nil, 0, err
+	}
+
+	length := int64(binary.LittleEndian.Uint32(header[0:]))
+	sum := binary.LittleEndian.Uint32(header[4:])
+
+	if offset+spoolHeaderSize+length > size {
+		return nil, 0, io.ErrUnexpectedEOF
+	}
+
+	payload := make([]byte, length)
+	if _, err := r.ReadAt(payload, offset+spoolHeaderSize); err != nil {
+		return nil, 0, err
+	}
+
+	if crc32.ChecksumIEEE(payload) != sum {
+		return nil, 0, errSpoolRecord
+	}
+
+	return payload, int64(spoolHeaderSize + len(payload)), nil
+}
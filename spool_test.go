@@ -0,0 +1,228 @@
+package eventlog
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyWriter хранилище, которое отклоняет запись, пока не сброшен признак down
+type flakyWriter struct {
+	mu     sync.Mutex
+	down   bool
+	events []Event
+	fails  int
+}
+
+func (w *flakyWriter) WriteEvents(events []Event) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.down {
+		w.fails++
+		return errors.New("sink is down")
+	}
+	w.events = append(w.events, events...)
+	return nil
+}
+
+func (w *flakyWriter) setDown(down bool) {
+	w.mu.Lock()
+	w.down = down
+	w.mu.Unlock()
+}
+
+// wait ожидает записи count событий и проверяет их порядок
+func (w *flakyWriter) wait(t *testing.T, count int) {
+
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		w.mu.Lock()
+		n := len(w.events)
+		w.mu.Unlock()
+		if n >= count {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.events) != count {
+		t.Fatalf("written %d events, want %d", len(w.events), count)
+	}
+	for i, e := range w.events {
+		if e.Offset != int64(i) {
+			t.Fatalf("event %d has offset %d, events out of order", i, e.Offset)
+		}
+	}
+}
+
+func TestSpool_Outage(t *testing.T) {
+
+	writer := &flakyWriter{down: true}
+
+	s, err := NewSpool(writer, SpoolOptions{
+		Dir:              t.TempDir(),
+		SegmentSize:      1024,
+		BatchSize:        7,
+		RetryInterval:    time.Millisecond,
+		MaxRetryInterval: 5 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	for i := 0; i < 100; i++ {
+		s.Push(Event{Offset: int64(i), Comment: "outage"})
+	}
+
+	// Пока хранилище недоступно, события копятся в нескольких сегментах
+	time.Sleep(20 * time.Millisecond)
+	stats := s.Stats()
+	if stats.Segments < 2 || stats.LastErr == nil {
+		t.Fatalf("stats during outage = %+v", stats)
+	}
+
+	writer.setDown(false)
+	writer.wait(t, 100)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for s.Stats().Pending != 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// Переданные сегменты удаляются
+	if stats := s.Stats(); stats.Segments != 1 || stats.Pending != 0 || stats.LastErr != nil {
+		t.Errorf("stats after recovery = %+v", stats)
+	}
+}
+
+func TestSpool_Restart(t *testing.T) {
+
+	dir := t.TempDir()
+	opts := SpoolOptions{
+		Dir:           dir,
+		SegmentSize:   1024,
+		RetryInterval: time.Hour,
+	}
+
+	s, err := NewSpool(&flakyWriter{down: true}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		s.Push(Event{Offset: int64(i)})
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Сбой во время записи оставляет в последнем сегменте оборванную запись
+	files, _ := filepath.Glob(filepath.Join(dir, "*"+spoolSegmentExt))
+	last, err := os.OpenFile(files[len(files)-1], os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = last.Write([]byte{200, 0, 0, 0, 1, 2, 3, 4, '{'})
+	_ = last.Close()
+
+	writer := &flakyWriter{}
+	s, err = NewSpool(writer, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.Push(Event{Offset: 50})
+	writer.wait(t, 51)
+
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Переданные события не передаются повторно
+	writer = &flakyWriter{}
+	s, err = NewSpool(writer, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	time.Sleep(20 * time.Millisecond)
+	writer.mu.Lock()
+	defer writer.mu.Unlock()
+	if n := len(writer.events); n != 0 {
+		t.Errorf("%d events written again after restart", n)
+	}
+}
+
+func TestSpool_MaxSize(t *testing.T) {
+
+	s, err := NewSpool(&flakyWriter{down: true}, SpoolOptions{
+		Dir:           t.TempDir(),
+		SegmentSize:   512,
+		MaxSize:       2048,
+		RetryInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	for i := 0; i < 200; i++ {
+		s.Push(Event{Offset: int64(i)})
+	}
+
+	stats := s.Stats()
+	if stats.Dropped == 0 {
+		t.Error("no segments dropped above max size")
+	}
+	if stats.Size > 2048 {
+		t.Errorf("spool size = %d, want <= 2048", stats.Size)
+	}
+}
+
+func TestSpool_WriteError(t *testing.T) {
+
+	dir := filepath.Join(t.TempDir(), "spool")
+
+	s, err := NewSpool(&flakyWriter{down: true}, SpoolOptions{
+		Dir:           dir,
+		SegmentSize:   1024,
+		RetryInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	// Каталог сегментов недоступен, новый сегмент не создается
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 100; i++ {
+		s.Push(Event{Offset: int64(i), Comment: "write error"})
+	}
+
+	if err := s.Flush(); err == nil {
+		t.Fatal("Flush() error = nil after lost events")
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 100; i++ {
+		s.Push(Event{Offset: int64(i), Comment: "write error"})
+	}
+
+	if err := s.Flush(); err != nil {
+		t.Errorf("Flush() error = %v after recovery", err)
+	}
+}
@@ -0,0 +1,136 @@
+package eventlog
+
+import "time"
+
+const eventDateLayout = `20060102150405`
+
+// localClock переводит время записей журнала в указанную временную зону.
+//
+// Время в журнале регистрации записывается по часам сервера без указания зоны.
+// При переходе на зимнее время один и тот же час повторяется дважды,
+// поэтому для неоднозначного времени учитывается порядок событий в файле:
+// выбирается самый ранний момент, который не раньше предыдущего события
+type localClock struct {
+	loc  *time.Location
+	last time.Time
+}
+
+func newLocalClock(loc *time.Location) *localClock {
+
+	if loc == nil {
+		loc = time.Local
+	}
+
+	return &localClock{
+		loc: loc,
+	}
+}
+
+// Resolve возвращает время в зоне часов для времени записи журнала.
+// Время записи передается как время по UTC без учета зоны сервера
+func (c *localClock) Resolve(wall time.Time) time.Time {
+
+	if wall.IsZero() {
+		return wall
+	}
+
+	t := c.resolve(wall)
+
+	if t.After(c.last) {
+		c.last = t
+	}
+
+	return t
+}
+
+// ResolveBefore возвращает время в зоне часов для времени, которое не позже указанного.
+// Используется для времени начала транзакции, которое предшествует времени события
+func (c *localClock) ResolveBefore(wall time.Time, ref time.Time) time.Time {
+
+	if wall.IsZero() {
+		return wall
+	}
+
+	candidates := localCandidates(wall, c.loc)
+
+	if len(candidates) == 0 {
+		return inLocation(wall, c.loc)
+	}
+
+	for i := len(candidates) - 1; i > 0; i-- {
+		if !candidates[i].After(ref) {
+			return candidates[i]
+		}
+	}
+
+	return candidates[0]
+}
+
+func (c *localClock) resolve(wall time.Time) time.Time {
+
+	candidates := localCandidates(wall, c.loc)
+
+	switch len(candidates) {
+	case 0:
+		// Время попало в пропущенный час перехода на летнее время
+		return inLocation(wall, c.loc)
+	case 1:
+		return candidates[0]
+	}
+
+	earlier, later := candidates[0], candidates[1]
+
+	if !c.last.IsZero() && earlier.Before(c.last) {
+		return later
+	}
+
+	return earlier
+}
+
+// localCandidates возвращает все моменты времени, для которых часы
+// в зоне loc показывают указанное время. Результат упорядочен по возрастанию
+func localCandidates(wall time.Time, loc *time.Location) []time.Time {
+
+	_, offsetBefore := inLocation(wall.AddDate(0, 0, -1), loc).Zone()
+	_, offsetAfter := inLocation(wall.AddDate(0, 0, 1), loc).Zone()
+
+	var candidates []time.Time
+
+	for _, offset := range []int{offsetBefore, offsetAfter} {
+
+		t := wall.Add(-time.Duration(offset) * time.Second).In(loc)
+
+		if !sameWallClock(t, wall) {
+			continue
+		}
+
+		if len(candidates) > 0 && candidates[0].Equal(t) {
+			continue
+		}
+
+		candidates = append(candidates, t)
+	}
+
+	if len(candidates) == 2 && candidates[1].Before(candidates[0]) {
+		candidates[0], candidates[1] = candidates[1], candidates[0]
+	}
+
+	return candidates
+}
+
+func inLocation(wall time.Time, loc *time.Location) time.Time {
+	return time.Date(wall.Year(), wall.Month(), wall.Day(),
+		wall.Hour(), wall.Minute(), wall.Second(), wall.Nanosecond(), loc)
+}
+
+func sameWallClock(t, wall time.Time) bool {
+
+	y1, m1, d1 := t.Date()
+	y2, m2, d2 := wall.Date()
+
+	return y1 == y2 && m1 == m2 && d1 == d2 &&
+		t.Hour() == wall.Hour() &&
+		t.Minute() == wall.Minute() &&
+		t.Second() == wall.Second() &&
+		t.Nanosecond() == wall.Nanosecond()
+}
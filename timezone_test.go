@@ -0,0 +1,124 @@
+package eventlog
+
+import (
+	"testing"
+	"time"
+)
+
+func wallTime(value string) time.Time {
+	t, _ := time.Parse(eventDateLayout, value)
+	return t
+}
+
+func Test_localClock_Resolve(t *testing.T) {
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	tests := []struct {
+		name string
+		loc  *time.Location
+		wall []string
+		want []string // RFC3339 по UTC
+	}{
+		{
+			"utc",
+			time.UTC,
+			[]string{"20210108102432"},
+			[]string{"2021-01-08T10:24:32Z"},
+		},
+		{
+			"winter",
+			berlin,
+			[]string{"20210108102432"},
+			[]string{"2021-01-08T09:24:32Z"},
+		},
+		{
+			"summer",
+			berlin,
+			[]string{"20210708102432"},
+			[]string{"2021-07-08T08:24:32Z"},
+		},
+		{
+			"ambiguous hour in order",
+			berlin,
+			[]string{
+				"20211031015959",
+				"20211031021000",
+				"20211031025000",
+				"20211031021500", // часы переведены назад
+				"20211031025900",
+				"20211031030000",
+			},
+			[]string{
+				"2021-10-30T23:59:59Z",
+				"2021-10-31T00:10:00Z",
+				"2021-10-31T00:50:00Z",
+				"2021-10-31T01:15:00Z",
+				"2021-10-31T01:59:00Z",
+				"2021-10-31T02:00:00Z",
+			},
+		},
+		{
+			"ambiguous hour first",
+			berlin,
+			[]string{"20211031023000"},
+			[]string{"2021-10-31T00:30:00Z"},
+		},
+		{
+			"skipped hour",
+			berlin,
+			[]string{"20210328015959", "20210328030000"},
+			[]string{"2021-03-28T00:59:59Z", "2021-03-28T01:00:00Z"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			clock := newLocalClock(tt.loc)
+
+			for i, wall := range tt.wall {
+
+				got := clock.Resolve(wallTime(wall))
+
+				if got.Location() != tt.loc {
+					t.Errorf("Resolve(%s) location = %v, want %v", wall, got.Location(), tt.loc)
+				}
+
+				if utc := got.UTC().Format(time.RFC3339); utc != tt.want[i] {
+					t.Errorf("Resolve(%s) = %s, want %s", wall, utc, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLgpReader_Location(t *testing.T) {
+
+	loc := time.FixedZone("MSK", 3*60*60)
+
+	r, err := NewLgpReader("./tests/20210108100000.lgp", LgpReaderOptions{
+		Location: loc,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	events, err := r.Read(1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event := events[0]
+
+	if got := event.Date.Format(eventDateLayout); got != "20210108102432" {
+		t.Errorf("Date = %s, want 20210108102432", got)
+	}
+
+	if want := "2021-01-08T07:24:32Z"; event.DateUTC.Format(time.RFC3339) != want {
+		t.Errorf("DateUTC = %s, want %s", event.DateUTC.Format(time.RFC3339), want)
+	}
+}
@@ -0,0 +1,111 @@
+package eventlog
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Время транзакции хранится в журнале как количество десятитысячных долей секунды
+// с 01.01.0001 в шестнадцатеричном виде
+const transactionTicksPerSecond = 10000
+
+var transactionEpoch = time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// DecodeTransactionDate переводит время транзакции из журнала во время без учета зоны (по UTC).
+// Дробная часть секунды сохраняется
+func DecodeTransactionDate(value string) time.Time {
+
+	ticks, err := strconv.ParseInt(value, 16, 64)
+	if err != nil || ticks <= 0 {
+		return time.Time{}
+	}
+
+	seconds := ticks / transactionTicksPerSecond
+	fraction := ticks % transactionTicksPerSecond
+
+	return time.Unix(transactionEpoch.Unix()+seconds,
+		fraction*int64(time.Second/transactionTicksPerSecond)).UTC()
+}
+
+// EncodeTransactionDate переводит время в формат журнала по показаниям часов t.
+// Точность ограничена десятитысячными долями секунды
+func EncodeTransactionDate(t time.Time) string {
+
+	if t.IsZero() {
+		return "0"
+	}
+
+	wall := inLocation(t, time.UTC)
+
+	// Разница с 01.01.0001 не помещается в time.Duration, поэтому считаем в секундах
+	seconds := wall.Unix() - transactionEpoch.Unix()
+	fraction := int64(wall.Nanosecond()) / int64(time.Second/transactionTicksPerSecond)
+
+	return strconv.FormatInt(seconds*transactionTicksPerSecond+fraction, 16)
+}
+
+// EncodeTransactionData возвращает узел транзакции в формате журнала, например {243c38504f680,20b}
+func EncodeTransactionData(number int64, date time.Time) string {
+	return fmt.Sprintf("{%s,%s}", EncodeTransactionDate(date), strconv.FormatInt(number, 16))
+}
+
+// TransactionKey идентифицирует транзакцию в журнале регистрации
+type TransactionKey struct {
+	Number     int64
+	Date       time.Time // Время начала транзакции по UTC
+	Connection int64
+}
+
+func (k TransactionKey) String() string {
+	return fmt.Sprintf("%d:%s:%d", k.Connection, k.Date.Format(time.RFC3339Nano), k.Number)
+}
+
+// TransactionKey возвращает ключ транзакции события.
+// Для событий вне транзакции возвращает false
+func (e Event) TransactionKey() (TransactionKey, bool) {
+
+	if e.TransactionNumber == 0 && e.TransactionDate.IsZero() {
+		return TransactionKey{}, false
+	}
+
+	return TransactionKey{
+		Number:     e.TransactionNumber,
+		Date:       e.TransactionDate.UTC(),
+		Connection: e.Connection,
+	}, true
+}
+
+// GroupByTransaction группирует события по транзакциям с сохранением порядка событий.
+// События вне транзакций пропускаются
+func GroupByTransaction(events []Event) map[TransactionKey][]Event {
+
+	groups := make(map[TransactionKey][]Event)
+
+	for _, event := range events {
+
+		key, ok := event.TransactionKey()
+		if !ok {
+			continue
+		}
+
+		groups[key] = append(groups[key], event)
+	}
+
+	return groups
+}
+
+// TransactionEvents возвращает все события указанной транзакции
+func TransactionEvents(events []Event, key TransactionKey) []Event {
+
+	var result []Event
+
+	for _, event := range events {
+
+		if eventKey, ok := event.TransactionKey(); ok && eventKey == key {
+			result = append(result, event)
+		}
+	}
+
+	return result
+}
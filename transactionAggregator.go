@@ -0,0 +1,211 @@
+package eventlog
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Transaction транзакция, восстановленная по событиям журнала регистрации
+type Transaction struct {
+	Key         TransactionKey
+	Status      TransactionStatusType
+	Start       time.Time // Время начала транзакции
+	End         time.Time // Время последнего события транзакции
+	User        string
+	UserUuid    string
+	Computer    string
+	Application ApplicationType
+	Connection  int64
+	Session     int64
+	Events      []Event
+
+	// TimedOut транзакция не получила новых событий в течении таймаута.
+	// Если при этом статус Не завершена, то транзакция считается зависшей
+	TimedOut bool
+}
+
+// Incomplete транзакция так и не была завершена
+func (t Transaction) Incomplete() bool {
+	return t.Status == TransactionStatusNotCompleted
+}
+
+func (t Transaction) Duration() time.Duration {
+	return t.End.Sub(t.Start)
+}
+
+var _ ExporterStorage = (*TransactionAggregator)(nil)
+
+// TransactionAggregator собирает события потока чтения в транзакции.
+//
+// Транзакция передается обработчику при получении события фиксации или отмены транзакции,
+// либо если по ней не было событий в течении Timeout. Время потока определяется по времени событий,
+// поэтому агрегатор одинаково работает при чтении архива и онлайн
+type TransactionAggregator struct {
+	Timeout time.Duration
+
+	handler func(tx Transaction)
+
+	mu     sync.Mutex
+	active map[TransactionKey]*Transaction
+	now    time.Time
+}
+
+// NewTransactionAggregator создает агрегатор транзакций, handler вызывается для каждой собранной транзакции
+func NewTransactionAggregator(timeout time.Duration, handler func(tx Transaction)) *TransactionAggregator {
+	return &TransactionAggregator{
+		Timeout: timeout,
+		handler: handler,
+		active:  make(map[TransactionKey]*Transaction),
+	}
+}
+
+// Push добавляет событие в транзакцию
+func (a *TransactionAggregator) Push(event Event) {
+
+	var completed []Transaction
+
+	a.mu.Lock()
+
+	if event.Date.After(a.now) {
+		a.now = event.Date
+	}
+
+	if key, ok := event.TransactionKey(); ok {
+
+		tx := a.add(key, event)
+
+		if _, end := transactionEnd(event); end {
+			delete(a.active, key)
+			completed = append(completed, *tx)
+		}
+	}
+
+	completed = append(completed, a.expire(a.now)...)
+
+	a.mu.Unlock()
+
+	a.emit(completed)
+}
+
+// Expire передает обработчику транзакции, по которым не было событий в течении таймаута на момент now.
+// Используется для онлайн чтения, когда новые события долго не поступают
+func (a *TransactionAggregator) Expire(now time.Time) {
+
+	a.mu.Lock()
+	completed := a.expire(now)
+	a.mu.Unlock()
+
+	a.emit(completed)
+}
+
+// Flush передает обработчику все накопленные транзакции, например по окончании чтения
+func (a *TransactionAggregator) Flush() {
+
+	a.mu.Lock()
+
+	completed := make([]Transaction, 0, len(a.active))
+	for key, tx := range a.active {
+		completed = append(completed, *tx)
+		delete(a.active, key)
+	}
+
+	a.mu.Unlock()
+
+	a.emit(completed)
+}
+
+// Active возвращает количество незавершенных транзакций
+func (a *TransactionAggregator) Active() int {
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return len(a.active)
+}
+
+func (a *TransactionAggregator) add(key TransactionKey, event Event) *Transaction {
+
+	tx, ok := a.active[key]
+	if !ok {
+		tx = &Transaction{
+			Key:         key,
+			Start:       event.TransactionDate,
+			Connection:  event.Connection,
+			Session:     event.Session,
+			User:        event.User,
+			UserUuid:    event.UserUuid,
+			Computer:    event.Computer,
+			Application: event.Application,
+		}
+		a.active[key] = tx
+	}
+
+	tx.Events = append(tx.Events, event)
+	tx.End = event.Date
+
+	if status, end := transactionEnd(event); end {
+		tx.Status = status
+	} else if len(event.TransactionStatus) > 0 {
+		tx.Status = event.TransactionStatus
+	}
+
+	return tx
+}
+
+// transactionEnd проверяет, что событие является фиксацией или отменой транзакции
+func transactionEnd(event Event) (TransactionStatusType, bool) {
+
+	if event.Event.Scope() != EventScopeTransaction {
+		return "", false
+	}
+
+	switch event.Event.Cause() {
+	case EventCauseCommit:
+		return TransactionStatusCommitted, true
+	case EventCauseRollback:
+		return TransactionStatusCanceled, true
+	}
+
+	return "", false
+}
+
+func (a *TransactionAggregator) expire(now time.Time) []Transaction {
+
+	if a.Timeout <= 0 {
+		return nil
+	}
+
+	var completed []Transaction
+
+	for key, tx := range a.active {
+
+		if now.Sub(tx.End) < a.Timeout {
+			continue
+		}
+
+		tx.TimedOut = true
+		completed = append(completed, *tx)
+		delete(a.active, key)
+	}
+
+	return completed
+}
+
+func (a *TransactionAggregator) emit(completed []Transaction) {
+
+	if a.handler == nil || len(completed) == 0 {
+		return
+	}
+
+	sort.Slice(completed, func(i, j int) bool {
+		if completed[i].Start.Equal(completed[j].Start) {
+			return completed[i].End.Before(completed[j].End)
+		}
+		return completed[i].Start.Before(completed[j].Start)
+	})
+
+	for _, tx := range completed {
+		a.handler(tx)
+	}
+}
@@ -0,0 +1,77 @@
+package eventlog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTransactionAggregator(t *testing.T) {
+
+	begin := time.Date(2021, 1, 8, 10, 24, 40, 0, time.UTC)
+
+	txEvent := func(connection int64, number int64, status TransactionStatusType, event EventType, sec int) Event {
+		return Event{
+			Date:              begin.Add(time.Duration(sec) * time.Second),
+			Connection:        connection,
+			Session:           connection * 10,
+			User:              "Администратор",
+			TransactionNumber: number,
+			TransactionDate:   begin,
+			TransactionStatus: status,
+			Event:             event,
+		}
+	}
+
+	events := []Event{
+		txEvent(1, 1, TransactionStatusCanceled, "_$Data$_.Update", 0),
+		txEvent(2, 2, TransactionStatusNotCompleted, "_$Data$_.New", 1),
+		txEvent(1, 1, TransactionStatusCanceled, "_$Transaction$_.Rollback", 2),
+		txEvent(3, 3, TransactionStatusCommitted, "_$Data$_.New", 3),
+		{Date: begin.Add(2 * time.Minute), TransactionStatus: TransactionStatusNoTransaction},
+		txEvent(4, 4, TransactionStatusCommitted, "_$Data$_.New", 121),
+	}
+
+	var got []Transaction
+
+	a := NewTransactionAggregator(time.Minute, func(tx Transaction) {
+		got = append(got, tx)
+	})
+
+	for _, event := range events {
+		a.Push(event)
+	}
+
+	if len(got) != 3 {
+		t.Fatalf("len(transactions) = %d, want 3", len(got))
+	}
+
+	canceled := got[0]
+	if canceled.Key.Connection != 1 || canceled.Status != TransactionStatusCanceled ||
+		len(canceled.Events) != 2 || canceled.TimedOut || canceled.Duration() != 2*time.Second {
+		t.Errorf("canceled transaction = %+v", canceled)
+	}
+
+	if canceled.User != "Администратор" || canceled.Session != 10 {
+		t.Errorf("canceled transaction user = %s, session = %d", canceled.User, canceled.Session)
+	}
+
+	stalled := got[1]
+	if stalled.Key.Connection != 2 || !stalled.Incomplete() || !stalled.TimedOut {
+		t.Errorf("stalled transaction = %+v", stalled)
+	}
+
+	committed := got[2]
+	if committed.Key.Connection != 3 || committed.Status != TransactionStatusCommitted || !committed.TimedOut {
+		t.Errorf("committed transaction = %+v", committed)
+	}
+
+	if a.Active() != 1 {
+		t.Errorf("Active() = %d, want 1", a.Active())
+	}
+
+	a.Flush()
+
+	if len(got) != 4 || got[3].Key.Connection != 4 || got[3].TimedOut {
+		t.Errorf("flushed transactions = %+v", got[3:])
+	}
+}
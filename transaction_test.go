@@ -0,0 +1,129 @@
+package eventlog
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/v8platform/brackets"
+)
+
+func TestDecodeTransactionDate(t *testing.T) {
+
+	tests := []struct {
+		name  string
+		value string
+		want  time.Time
+	}{
+		{
+			"event file",
+			"243c38504f680",
+			time.Date(2021, 1, 8, 10, 24, 40, 0, time.UTC),
+		},
+		{
+			"fraction",
+			"243c38504f6a3",
+			time.Date(2021, 1, 8, 10, 24, 40, 35*int(100*time.Microsecond), time.UTC),
+		},
+		{
+			"no transaction",
+			"0",
+			time.Time{},
+		},
+		{
+			"broken",
+			"zz",
+			time.Time{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			got := DecodeTransactionDate(tt.value)
+			if !got.Equal(tt.want) {
+				t.Errorf("DecodeTransactionDate() = %v, want %v", got, tt.want)
+			}
+
+			if tt.want.IsZero() {
+				return
+			}
+
+			if encoded := EncodeTransactionDate(got); encoded != tt.value {
+				t.Errorf("EncodeTransactionDate() = %v, want %v", encoded, tt.value)
+			}
+		})
+	}
+}
+
+func Test_getTransactionData(t *testing.T) {
+
+	date := time.Date(2020, 10, 5, 11, 48, 46, int(1234*100*time.Microsecond), time.UTC)
+
+	node, _ := brackets.NewParser(strings.NewReader(EncodeTransactionData(523, date))).NextNode()
+
+	number, got := getTransactionData(node)
+
+	if number != 523 {
+		t.Errorf("getTransactionData() number = %v, want 523", number)
+	}
+
+	if !got.Equal(date) {
+		t.Errorf("getTransactionData() date = %v, want %v", got, date)
+	}
+}
+
+func TestGroupByTransaction(t *testing.T) {
+
+	begin := time.Date(2021, 1, 8, 10, 24, 40, 0, time.UTC)
+
+	events := []Event{
+		{Offset: 1, Connection: 4, TransactionNumber: 523, TransactionDate: begin, TransactionStatus: TransactionStatusCanceled},
+		{Offset: 2, Connection: 4, TransactionStatus: TransactionStatusNoTransaction},
+		{Offset: 3, Connection: 5, TransactionNumber: 523, TransactionDate: begin, TransactionStatus: TransactionStatusCommitted},
+		{Offset: 4, Connection: 4, TransactionNumber: 523, TransactionDate: begin.In(time.FixedZone("MSK", 3*60*60)), TransactionStatus: TransactionStatusCanceled},
+	}
+
+	groups := GroupByTransaction(events)
+
+	if len(groups) != 2 {
+		t.Fatalf("len(groups) = %d, want 2", len(groups))
+	}
+
+	key, _ := events[0].TransactionKey()
+	got := TransactionEvents(events, key)
+
+	if len(got) != 2 || got[0].Offset != 1 || got[1].Offset != 4 {
+		t.Errorf("TransactionEvents() = %v", got)
+	}
+
+	if len(groups[key]) != 2 {
+		t.Errorf("groups[%s] = %v", key, groups[key])
+	}
+}
+
+func TestLgpReader_TransactionData(t *testing.T) {
+
+	r, err := NewLgpReader("./tests/20210108100000.lgp", LgpReaderOptions{
+		Location: time.UTC,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	events, _ := r.Read(5, 0)
+
+	event := events[4]
+
+	if event.TransactionStatus != TransactionStatusCanceled {
+		t.Fatalf("TransactionStatus = %v", event.TransactionStatus)
+	}
+
+	if event.TransactionNumber != 0x20b {
+		t.Errorf("TransactionNumber = %v, want %v", event.TransactionNumber, 0x20b)
+	}
+
+	if !event.TransactionDate.Equal(event.Date) {
+		t.Errorf("TransactionDate = %v, want %v", event.TransactionDate, event.Date)
+	}
+}